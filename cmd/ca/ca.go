@@ -0,0 +1,18 @@
+package ca
+
+import "github.com/spf13/cobra"
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "local certificate authority related commands",
+		Long:  "manage a local certificate authority, backed by a directory on disk, for issuing throwaway certificates",
+	}
+
+	cmd.AddCommand(newInitCommand())
+	cmd.AddCommand(newIssueCommand())
+	cmd.AddCommand(newRevokeCommand())
+	cmd.AddCommand(newCRLCommand())
+
+	return cmd
+}
@@ -0,0 +1,150 @@
+package ca
+
+import (
+	"crypto/x509"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simondrake/genc/internal/keys"
+)
+
+func TestCA(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+
+	err := initCA(dir, initOptions{
+		subject:             "CN=Test Root CA",
+		intermediateSubject: "CN=Test Intermediate CA",
+		algorithm:           keys.ECDSA,
+		curve:               "P-256",
+		days:                30,
+		permittedDNS:        []string{"example.com"},
+		permittedIP:         []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("initCA returned an error when one wasn't expected: %+v", err)
+	}
+
+	if err := initCA(dir, initOptions{subject: "CN=Test Root CA"}); err == nil {
+		t.Errorf("expected an error when the certificate authority already exists")
+	}
+
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatalf("openStore returned an error when one wasn't expected: %+v", err)
+	}
+
+	opts := issueOptions{
+		sans:      []string{"www.example.com", "10.0.0.1"},
+		profile:   profileServer,
+		days:      1,
+		algorithm: keys.RSA,
+		bits:      2048,
+	}
+
+	var issued *x509.Certificate
+
+	t.Run("Issue", func(t *testing.T) {
+		cert, key, err := issue(s, opts)
+		if err != nil {
+			t.Fatalf("issue returned an error when one wasn't expected: %+v", err)
+		}
+
+		if key == nil {
+			t.Fatalf("expected a private key to be generated")
+		}
+
+		if cert.Subject.CommonName != "www.example.com" {
+			t.Errorf("expected the common name to default to the first SAN, got %q", cert.Subject.CommonName)
+		}
+
+		if cert.Issuer.CommonName != "Test Intermediate CA" {
+			t.Errorf("expected the certificate to be issued by the intermediate, got %q", cert.Issuer.CommonName)
+		}
+
+		if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+			t.Errorf("unexpected extended key usage %v", cert.ExtKeyUsage)
+		}
+
+		// The root and intermediate use serials 01 and 02
+		if cert.SerialNumber.Cmp(big.NewInt(3)) != 0 {
+			t.Errorf("expected serial number 3, got %s", cert.SerialNumber)
+		}
+
+		issued = cert
+	})
+
+	t.Run("NameConstraints", func(t *testing.T) {
+		o := opts
+		o.sans = []string{"wibble.com"}
+
+		if _, _, err := issue(s, o); err == nil {
+			t.Errorf("expected an error issuing a certificate outside of the name constraints")
+		}
+
+		records, err := s.index()
+		if err != nil {
+			t.Fatalf("index returned an error when one wasn't expected: %+v", err)
+		}
+
+		if len(records) != 1 {
+			t.Errorf("expected only the valid certificate to be recorded, got %d records", len(records))
+		}
+
+		// The refused certificate shouldn't use up a serial
+		serial, err := s.peekSerial()
+		if err != nil {
+			t.Fatalf("peekSerial returned an error when one wasn't expected: %+v", err)
+		}
+
+		if serial.Cmp(big.NewInt(4)) != 0 {
+			t.Errorf("expected the next serial number to be 4, got %s", serial)
+		}
+	})
+
+	t.Run("RevokeAndCRL", func(t *testing.T) {
+		if issued == nil {
+			t.Skip("no certificate was issued")
+		}
+
+		if err := revoke(s, issued.SerialNumber, "keyCompromise", time.Now()); err != nil {
+			t.Fatalf("revoke returned an error when one wasn't expected: %+v", err)
+		}
+
+		if err := revoke(s, issued.SerialNumber, "keyCompromise", time.Now()); err == nil {
+			t.Errorf("expected an error revoking a certificate twice")
+		}
+
+		if err := revoke(s, big.NewInt(100), "unspecified", time.Now()); err == nil {
+			t.Errorf("expected an error revoking an unknown certificate")
+		}
+
+		der, err := createCRL(s, time.Now(), 7)
+		if err != nil {
+			t.Fatalf("createCRL returned an error when one wasn't expected: %+v", err)
+		}
+
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			t.Fatalf("ParseRevocationList returned an error when one wasn't expected: %+v", err)
+		}
+
+		intermediate, _, err := s.signer()
+		if err != nil {
+			t.Fatalf("signer returned an error when one wasn't expected: %+v", err)
+		}
+
+		if err := crl.CheckSignatureFrom(intermediate); err != nil {
+			t.Errorf("CheckSignatureFrom returned an error when one wasn't expected: %+v", err)
+		}
+
+		if len(crl.RevokedCertificateEntries) != 1 || crl.RevokedCertificateEntries[0].SerialNumber.Cmp(issued.SerialNumber) != 0 {
+			t.Fatalf("expected the CRL to contain the revoked certificate, got %+v", crl.RevokedCertificateEntries)
+		}
+
+		if crl.RevokedCertificateEntries[0].ReasonCode != 1 {
+			t.Errorf("expected reason code 1, got %d", crl.RevokedCertificateEntries[0].ReasonCode)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package ca
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newCRLCommand() *cobra.Command {
+	var (
+		dir  string
+		days int
+		out  string
+	)
+
+	crlCmd := &cobra.Command{
+		Use:   "crl",
+		Short: "create a certificate revocation list",
+		Long:  "create a certificate revocation list, signed by the same certificate authority that issues leaf certificates, containing every revoked certificate",
+		Example: `
+    # Create a CRL, valid for a week
+    $ genc ca crl --out ca.crl
+
+    # Check a certificate against the CRL with OpenSSL
+    $ cat ca/root.crt ca/intermediate.crt > chain.crt
+    $ openssl verify -crl_check -CAfile chain.crt -CRLfile ca.crl server.crt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := openStore(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error opening certificate authority: %w", err))
+				os.Exit(1)
+			}
+
+			der, err := createCRL(s, time.Now(), days)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error creating crl: %w", err))
+				os.Exit(1)
+			}
+
+			if err := output.WriteFile(out, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing crl: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	crlCmd.Flags().StringVar(&dir, "dir", "ca", "the directory the certificate authority is stored in")
+	crlCmd.Flags().IntVar(&days, "days", 7, "the number of days until the next CRL update")
+	crlCmd.Flags().StringVar(&out, "out", "", "the location to write the crl to, defaults to stdout")
+
+	return crlCmd
+}
+
+func createCRL(s *store, now time.Time, days int) ([]byte, error) {
+	issuer, key, err := s.signer()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := s.nextCRLNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.RevocationList{
+		Number:     number,
+		ThisUpdate: now,
+		NextUpdate: now.AddDate(0, 0, days),
+	}
+
+	for _, r := range records {
+		if r.RevokedAt == nil {
+			continue
+		}
+
+		sn, err := parseSerial(r.Serial)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   sn,
+			RevocationTime: *r.RevokedAt,
+			ReasonCode:     r.Reason.code(),
+		})
+	}
+
+	return x509.CreateRevocationList(rand.Reader, tmpl, issuer, key)
+}
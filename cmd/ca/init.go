@@ -0,0 +1,205 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+)
+
+type initOptions struct {
+	subject             string
+	intermediateSubject string
+	algorithm           keys.Algorithm
+	bits                int
+	curve               string
+	days                int
+	permittedDNS        []string
+	excludedDNS         []string
+	permittedIP         []string
+	excludedIP          []string
+	permittedEmail      []string
+}
+
+func (o initOptions) hasNameConstraints() bool {
+	return len(o.permittedDNS)+len(o.excludedDNS)+len(o.permittedIP)+len(o.excludedIP)+len(o.permittedEmail) > 0
+}
+
+func newInitCommand() *cobra.Command {
+	var dir string
+
+	opts := initOptions{algorithm: keys.RSA}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "create a new certificate authority",
+		Long:  "create a new root certificate authority, and optionally an intermediate that is used to sign leaf certificates",
+		Example: `
+    # Create a root CA in ./ca
+    $ genc ca init
+
+    # Create a root CA and an intermediate, that can only issue certificates for example.com and 10.0.0.0/8
+    $ genc ca init --dir test-ca --subject "CN=Test Root CA" --intermediate-subject "CN=Test Intermediate CA" --permitted-dns example.com --permitted-ip 10.0.0.0/8`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := initCA(dir, opts); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error creating certificate authority: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	initCmd.Flags().StringVar(&dir, "dir", "ca", "the directory to store the certificate authority in")
+	initCmd.Flags().StringVar(&opts.subject, "subject", "CN=genc Root CA", "the subject of the root certificate")
+	initCmd.Flags().StringVar(&opts.intermediateSubject, "intermediate-subject", "", "the subject of the intermediate certificate, if one should be created")
+	initCmd.Flags().Var(&opts.algorithm, "algorithm", "the key algorithm")
+	initCmd.Flags().IntVar(&opts.bits, "bits", 2048, "the size of the key, for rsa keys")
+	initCmd.Flags().StringVar(&opts.curve, "curve", "P-256", "the curve, for ecdsa keys (P-224, P-256, P-384 or P-521)")
+	initCmd.Flags().IntVar(&opts.days, "days", 3650, "the number of days the certificate authority is valid for")
+	initCmd.Flags().StringSliceVar(&opts.permittedDNS, "permitted-dns", nil, "DNS domains the intermediate is permitted to issue certificates for")
+	initCmd.Flags().StringSliceVar(&opts.excludedDNS, "excluded-dns", nil, "DNS domains the intermediate is not permitted to issue certificates for")
+	initCmd.Flags().StringSliceVar(&opts.permittedIP, "permitted-ip", nil, "CIDRs the intermediate is permitted to issue certificates for")
+	initCmd.Flags().StringSliceVar(&opts.excludedIP, "excluded-ip", nil, "CIDRs the intermediate is not permitted to issue certificates for")
+	initCmd.Flags().StringSliceVar(&opts.permittedEmail, "permitted-email", nil, "email addresses, or domains, the intermediate is permitted to issue certificates for")
+
+	return initCmd
+}
+
+func initCA(dir string, opts initOptions) error {
+	if opts.hasNameConstraints() && opts.intermediateSubject == "" {
+		return fmt.Errorf("name constraints can only be applied to an intermediate, use --intermediate-subject")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, rootCertFile)); err == nil {
+		return fmt.Errorf("'%s' is already a certificate authority", dir)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, certsDir), 0o700); err != nil {
+		return err
+	}
+
+	s := &store{dir: dir}
+
+	for name, content := range map[string]string{serialFile: "01\n", crlNumberFile: "01\n", indexFile: "[]\n"} {
+		if err := os.WriteFile(s.path(name), []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	subject, err := certs.ParseSubject(opts.subject)
+	if err != nil {
+		return err
+	}
+
+	rootTmpl, err := s.caTemplate(subject, time.Now().AddDate(0, 0, opts.days))
+	if err != nil {
+		return err
+	}
+
+	rootKey, err := keys.Generate(opts.algorithm, opts.bits, opts.curve)
+	if err != nil {
+		return err
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, rootKey.Public(), rootKey)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writePair(rootCertFile, rootKeyFile, rootDER, rootKey); err != nil {
+		return err
+	}
+
+	if opts.intermediateSubject == "" {
+		return nil
+	}
+
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		return err
+	}
+
+	return s.createIntermediate(root, rootKey, opts)
+}
+
+func (s *store) createIntermediate(root *x509.Certificate, rootKey crypto.Signer, opts initOptions) error {
+	subject, err := certs.ParseSubject(opts.intermediateSubject)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := s.caTemplate(subject, root.NotAfter)
+	if err != nil {
+		return err
+	}
+
+	// The intermediate can only sign leaf certificates
+	tmpl.MaxPathLenZero = true
+
+	tmpl.PermittedDNSDomains = opts.permittedDNS
+	tmpl.ExcludedDNSDomains = opts.excludedDNS
+	tmpl.PermittedEmailAddresses = opts.permittedEmail
+	tmpl.PermittedDNSDomainsCritical = opts.hasNameConstraints()
+
+	if tmpl.PermittedIPRanges, err = parseCIDRs(opts.permittedIP); err != nil {
+		return err
+	}
+
+	if tmpl.ExcludedIPRanges, err = parseCIDRs(opts.excludedIP); err != nil {
+		return err
+	}
+
+	key, err := keys.Generate(opts.algorithm, opts.bits, opts.curve)
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, root, key.Public(), rootKey)
+	if err != nil {
+		return err
+	}
+
+	return s.writePair(intermediateCertFile, intermediateKeyFile, der, key)
+}
+
+// caTemplate returns a template for a certificate authority, using the next
+// serial number from the store.
+func (s *store) caTemplate(subject pkix.Name, notAfter time.Time) (*x509.Certificate, error) {
+	tmpl, err := certs.NewTemplate(subject, notAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl.SerialNumber, err = s.nextSerial(); err != nil {
+		return nil, err
+	}
+
+	tmpl.IsCA = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return tmpl, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
@@ -0,0 +1,322 @@
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+	"github.com/simondrake/genc/internal/output"
+)
+
+type issueOptions struct {
+	csr         []byte
+	subject     string
+	sans        []string
+	profile     profile
+	extKeyUsage []string
+	days        int
+	algorithm   keys.Algorithm
+	bits        int
+	curve       string
+}
+
+func newIssueCommand() *cobra.Command {
+	var (
+		dir     string
+		csrFile string
+		out     string
+		keyOut  string
+		chain   bool
+	)
+
+	opts := issueOptions{profile: profileServer, algorithm: keys.RSA}
+
+	issueCmd := &cobra.Command{
+		Use:   "issue",
+		Short: "issue a leaf certificate",
+		Long:  "issue a leaf certificate, signed by the intermediate if there is one and the root otherwise, either for a newly generated key or from a certificate signing request",
+		Example: `
+    # Issue a server certificate, writing the new private key to server.key
+    $ genc ca issue --san example.com --san 127.0.0.1 --out server.crt --key-out server.key
+
+    # Issue a client certificate, valid for a day, including the intermediate in the output
+    $ genc ca issue --dir test-ca --profile client --subject "CN=wibble" --days 1 --chain --out client.crt --key-out client.key
+
+    # Issue a certificate from a CSR
+    $ genc ca issue --csr domain.csr --out domain.crt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if csrFile == "" && keyOut == "" {
+				fmt.Fprintln(os.Stderr, errors.New("error issuing certificate: --key-out is required when a key is being generated"))
+				os.Exit(1)
+			}
+
+			s, err := openStore(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error opening certificate authority: %w", err))
+				os.Exit(1)
+			}
+
+			if csrFile != "" {
+				opts.csr, err = os.ReadFile(csrFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading csr: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			cert, key, err := issue(s, opts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error issuing certificate: %w", err))
+				os.Exit(1)
+			}
+
+			var buf bytes.Buffer
+
+			if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error encoding certificate: %w", err))
+				os.Exit(1)
+			}
+
+			if chain {
+				intermediates, err := s.chain()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading certificate chain: %w", err))
+					os.Exit(1)
+				}
+
+				for _, c := range intermediates {
+					if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+						fmt.Fprintln(os.Stderr, fmt.Errorf("error encoding certificate chain: %w", err))
+						os.Exit(1)
+					}
+				}
+			}
+
+			if key != nil {
+				b, err := keys.MarshalPrivateKey(key, keys.PKCS8)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error marshalling private key: %w", err))
+					os.Exit(1)
+				}
+
+				if err := os.WriteFile(keyOut, pem.EncodeToMemory(b), 0o600); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error writing private key: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			if err := output.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing certificate: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	issueCmd.Flags().StringVar(&dir, "dir", "ca", "the directory the certificate authority is stored in")
+	issueCmd.Flags().StringVar(&csrFile, "csr", "", "the location of a certificate signing request on disk, instead of generating a key")
+	issueCmd.Flags().StringVar(&opts.subject, "subject", "", "the subject, defaults to the subject of the csr or a common name of the first subject alternative name")
+	issueCmd.Flags().StringSliceVar(&opts.sans, "san", nil, "subject alternative names, defaults to those in the csr")
+	issueCmd.Flags().Var(&opts.profile, "profile", "the type of certificate, which determines the extended key usages")
+	issueCmd.Flags().StringSliceVar(&opts.extKeyUsage, "ext-key-usage", nil, "extended key usages, overriding those of the profile")
+	issueCmd.Flags().IntVar(&opts.days, "days", 90, "the number of days the certificate is valid for")
+	issueCmd.Flags().Var(&opts.algorithm, "algorithm", "the key algorithm, when generating a key")
+	issueCmd.Flags().IntVar(&opts.bits, "bits", 2048, "the size of the key, for rsa keys")
+	issueCmd.Flags().StringVar(&opts.curve, "curve", "P-256", "the curve, for ecdsa keys (P-224, P-256, P-384 or P-521)")
+	issueCmd.Flags().StringVar(&out, "out", "", "the location to write the certificate to, defaults to stdout")
+	issueCmd.Flags().StringVar(&keyOut, "key-out", "", "the location to write the generated private key to")
+	issueCmd.Flags().BoolVar(&chain, "chain", false, "whether to include the intermediate certificate in the output")
+
+	issueCmd.MarkFlagsMutuallyExclusive("csr", "key-out")
+
+	return issueCmd
+}
+
+// issue signs a new leaf certificate, and records it in the store. The
+// generated private key is returned if a CSR wasn't provided.
+func issue(s *store, opts issueOptions) (*x509.Certificate, crypto.Signer, error) {
+	signerCert, signerKey, err := s.signer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		key  crypto.Signer
+		pub  crypto.PublicKey
+		tmpl *x509.Certificate
+	)
+
+	tmpl, err = certs.NewTemplate(pkix.Name{}, time.Now().AddDate(0, 0, opts.days))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(opts.csr) > 0 {
+		csr, err := parseCSR(opts.csr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pub = csr.PublicKey
+		tmpl.Subject = csr.Subject
+		tmpl.DNSNames, tmpl.IPAddresses, tmpl.EmailAddresses, tmpl.URIs = csr.DNSNames, csr.IPAddresses, csr.EmailAddresses, csr.URIs
+	} else {
+		if key, err = keys.Generate(opts.algorithm, opts.bits, opts.curve); err != nil {
+			return nil, nil, err
+		}
+
+		pub = key.Public()
+	}
+
+	if len(opts.sans) > 0 {
+		sans, err := certs.ParseSANs(opts.sans)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tmpl.DNSNames, tmpl.IPAddresses, tmpl.EmailAddresses, tmpl.URIs = sans.DNSNames, sans.IPAddresses, sans.EmailAddresses, sans.URIs
+	}
+
+	if err := setLeafSubject(tmpl, opts.subject, len(opts.csr) > 0); err != nil {
+		return nil, nil, err
+	}
+
+	if tmpl.NotAfter.After(signerCert.NotAfter) {
+		tmpl.NotAfter = signerCert.NotAfter
+	}
+
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	tmpl.ExtKeyUsage = opts.profile.extKeyUsage()
+	if len(opts.extKeyUsage) > 0 {
+		if tmpl.ExtKeyUsage, err = certs.ParseExtKeyUsage(opts.extKeyUsage); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The serial is only used up once the certificate has been verified, so
+	// that a certificate that is refused doesn't leave a gap
+	if tmpl.SerialNumber, err = s.peekSerial(); err != nil {
+		return nil, nil, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, pub, signerKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse to record certificates that wouldn't verify, such as those
+	// outside of the intermediate's name constraints
+	if err := s.verify(cert); err != nil {
+		return nil, nil, fmt.Errorf("issued certificate is not valid for this certificate authority: %w", err)
+	}
+
+	if _, err := s.nextSerial(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.addCertificate(cert); err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// setLeafSubject sets the subject of tmpl, falling back to a common name of
+// the first subject alternative name.
+func setLeafSubject(tmpl *x509.Certificate, subject string, fromCSR bool) error {
+	if subject != "" {
+		name, err := certs.ParseSubject(subject)
+		if err != nil {
+			return err
+		}
+
+		tmpl.Subject = name
+
+		return nil
+	}
+
+	if fromCSR && tmpl.Subject.String() != "" {
+		return nil
+	}
+
+	switch {
+	case len(tmpl.DNSNames) > 0:
+		tmpl.Subject.CommonName = tmpl.DNSNames[0]
+	case len(tmpl.IPAddresses) > 0:
+		tmpl.Subject.CommonName = tmpl.IPAddresses[0].String()
+	case len(tmpl.EmailAddresses) > 0:
+		tmpl.Subject.CommonName = tmpl.EmailAddresses[0]
+	case len(tmpl.URIs) > 0:
+		tmpl.Subject.CommonName = tmpl.URIs[0].String()
+	default:
+		return errors.New("a subject or at least one subject alternative name is required")
+	}
+
+	return nil
+}
+
+func (s *store) verify(cert *x509.Certificate) error {
+	root, err := s.root()
+	if err != nil {
+		return err
+	}
+
+	intermediates, err := s.chain()
+	if err != nil {
+		return err
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	opts.Roots.AddCert(root)
+
+	for _, c := range intermediates {
+		opts.Intermediates.AddCert(c)
+	}
+
+	_, err = cert.Verify(opts)
+
+	return err
+}
+
+func parseCSR(b []byte) (*x509.CertificateRequest, error) {
+	der := b
+	if p, _ := pem.Decode(b); p != nil {
+		der = p.Bytes
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing csr: %w", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("error checking csr signature: %w", err)
+	}
+
+	return csr, nil
+}
@@ -0,0 +1,48 @@
+// profile implements a custom type to be used with Cobra.
+//
+// It ensures that the leaf certificate profile is one of server, client or
+// peer, which is both a server and a client.
+
+package ca
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+type profile string
+
+const (
+	profileServer profile = "server"
+	profileClient profile = "client"
+	profilePeer   profile = "peer"
+)
+
+func (p *profile) String() string {
+	return string(*p)
+}
+
+func (p *profile) Set(v string) error {
+	switch profile(v) {
+	case profileServer, profileClient, profilePeer:
+		*p = profile(v)
+		return nil
+	default:
+		return errors.New(`must be one of server, client, or peer`)
+	}
+}
+
+func (p *profile) Type() string {
+	return "[server,client,peer]"
+}
+
+func (p profile) extKeyUsage() []x509.ExtKeyUsage {
+	switch p {
+	case profileServer:
+		return []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case profileClient:
+		return []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+
+	return []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+}
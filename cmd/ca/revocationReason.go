@@ -0,0 +1,63 @@
+// revocationReason implements a custom type to be used with Cobra.
+//
+// It ensures that the reason a certificate is being revoked is one of the
+// CRL reason codes from RFC 5280.
+
+package ca
+
+import (
+	"errors"
+	"strings"
+)
+
+type revocationReason string
+
+var revocationReasons = []struct {
+	reason revocationReason
+	code   int
+}{
+	{"unspecified", 0},
+	{"keyCompromise", 1},
+	{"cACompromise", 2},
+	{"affiliationChanged", 3},
+	{"superseded", 4},
+	{"cessationOfOperation", 5},
+	{"certificateHold", 6},
+	{"privilegeWithdrawn", 9},
+	{"aACompromise", 10},
+}
+
+func (r *revocationReason) String() string {
+	return string(*r)
+}
+
+func (r *revocationReason) Set(v string) error {
+	for _, rr := range revocationReasons {
+		if string(rr.reason) == v {
+			*r = rr.reason
+			return nil
+		}
+	}
+
+	return errors.New(`must be one of unspecified, keyCompromise, cACompromise, affiliationChanged, superseded, cessationOfOperation, certificateHold, privilegeWithdrawn, or aACompromise`)
+}
+
+func (r *revocationReason) Type() string {
+	s := make([]string, len(revocationReasons))
+	for i, rr := range revocationReasons {
+		s[i] = string(rr.reason)
+	}
+
+	return "[" + strings.Join(s, ",") + "]"
+}
+
+// code returns the RFC 5280 reason code.
+func (r revocationReason) code() int {
+	for _, rr := range revocationReasons {
+		if rr.reason == r {
+			return rr.code
+		}
+	}
+
+	return 0
+}
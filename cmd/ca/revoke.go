@@ -0,0 +1,116 @@
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newRevokeCommand() *cobra.Command {
+	var (
+		dir      string
+		serial   string
+		certFile string
+	)
+
+	reason := revocationReason("unspecified")
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "revoke an issued certificate",
+		Long:  "revoke a certificate issued by the certificate authority, so that it is included in the next CRL",
+		Example: `
+    # Revoke a certificate by its serial number
+    $ genc ca revoke --serial 03 --reason keyCompromise
+
+    # Revoke a certificate
+    $ genc ca revoke --dir test-ca --cert server.crt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := openStore(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error opening certificate authority: %w", err))
+				os.Exit(1)
+			}
+
+			var sn *big.Int
+
+			if certFile != "" {
+				sn, err = certificateSerial(certFile)
+			} else {
+				sn, err = parseSerial(serial)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading serial number: %w", err))
+				os.Exit(1)
+			}
+
+			if err := revoke(s, sn, reason, time.Now()); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error revoking certificate: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	revokeCmd.Flags().StringVar(&dir, "dir", "ca", "the directory the certificate authority is stored in")
+	revokeCmd.Flags().StringVar(&serial, "serial", "", "the serial number, in hex, of the certificate to revoke")
+	revokeCmd.Flags().StringVar(&certFile, "cert", "", "the location of the certificate to revoke on disk")
+	revokeCmd.Flags().Var(&reason, "reason", "the reason the certificate is being revoked")
+
+	revokeCmd.MarkFlagsOneRequired("serial", "cert")
+	revokeCmd.MarkFlagsMutuallyExclusive("serial", "cert")
+
+	return revokeCmd
+}
+
+// revoke marks the certificate with the serial number sn as revoked.
+func revoke(s *store, sn *big.Int, reason revocationReason, at time.Time) error {
+	records, err := s.index()
+	if err != nil {
+		return err
+	}
+
+	serial := formatSerial(sn)
+
+	for i, r := range records {
+		if r.Serial != serial {
+			continue
+		}
+
+		if r.RevokedAt != nil {
+			return fmt.Errorf("certificate %s was already revoked at %s", serial, r.RevokedAt.Format(time.RFC3339))
+		}
+
+		records[i].RevokedAt = &at
+		records[i].Reason = reason
+
+		return s.writeIndex(records)
+	}
+
+	return fmt.Errorf("certificate %s was not issued by this certificate authority", serial)
+}
+
+func certificateSerial(path string) (*big.Int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := pem.Decode(b)
+	if p == nil || p.Type != "CERTIFICATE" {
+		return nil, errors.New("file does not contain a PEM encoded certificate")
+	}
+
+	c, err := x509.ParseCertificate(p.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.SerialNumber, nil
+}
@@ -0,0 +1,265 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simondrake/genc/internal/keys"
+)
+
+// The layout of a CA directory:
+//
+//	root.crt, root.key                  the root certificate and key
+//	intermediate.crt, intermediate.key  the optional intermediate, which signs leaf certificates
+//	serial                              the next serial number, in hex
+//	crlnumber                           the next CRL number, in hex
+//	index.json                          the certificates issued by the signing CA
+//	certs/<serial>.crt                  a copy of each issued certificate
+const (
+	rootCertFile         = "root.crt"
+	rootKeyFile          = "root.key"
+	intermediateCertFile = "intermediate.crt"
+	intermediateKeyFile  = "intermediate.key"
+	serialFile           = "serial"
+	crlNumberFile        = "crlnumber"
+	indexFile            = "index.json"
+	certsDir             = "certs"
+)
+
+// record is an entry in the index of issued certificates.
+type record struct {
+	Serial    string           `json:"serial"`
+	Subject   string           `json:"subject"`
+	NotAfter  time.Time        `json:"notAfter"`
+	RevokedAt *time.Time       `json:"revokedAt,omitempty"`
+	Reason    revocationReason `json:"reason,omitempty"`
+}
+
+type store struct {
+	dir string
+}
+
+// openStore returns the store in dir, which must have been created with
+// genc ca init.
+func openStore(dir string) (*store, error) {
+	if _, err := os.Stat(filepath.Join(dir, rootCertFile)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("'%s' is not a certificate authority, run genc ca init first", dir)
+		}
+
+		return nil, err
+	}
+
+	return &store{dir: dir}, nil
+}
+
+func (s *store) path(name ...string) string {
+	return filepath.Join(append([]string{s.dir}, name...)...)
+}
+
+func (s *store) hasIntermediate() bool {
+	_, err := os.Stat(s.path(intermediateCertFile))
+	return err == nil
+}
+
+// signer returns the certificate and key used to sign leaf certificates and
+// CRLs, which is the intermediate if there is one, and the root otherwise.
+func (s *store) signer() (*x509.Certificate, crypto.Signer, error) {
+	if s.hasIntermediate() {
+		return s.loadPair(intermediateCertFile, intermediateKeyFile)
+	}
+
+	return s.loadPair(rootCertFile, rootKeyFile)
+}
+
+// chain returns the certificates from the signing CA up to, but not
+// including, the root.
+func (s *store) chain() ([]*x509.Certificate, error) {
+	if !s.hasIntermediate() {
+		return nil, nil
+	}
+
+	c, err := s.loadCert(intermediateCertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*x509.Certificate{c}, nil
+}
+
+func (s *store) root() (*x509.Certificate, error) {
+	return s.loadCert(rootCertFile)
+}
+
+func (s *store) loadCert(name string) (*x509.Certificate, error) {
+	b, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := pem.Decode(b)
+	if p == nil || p.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("'%s' does not contain a PEM encoded certificate", name)
+	}
+
+	return x509.ParseCertificate(p.Bytes)
+}
+
+func (s *store) loadPair(certName, keyName string) (*x509.Certificate, crypto.Signer, error) {
+	c, err := s.loadCert(certName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k, err := keys.LoadPrivateKey(s.path(keyName), keys.Passphrase(""))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", k)
+	}
+
+	return c, signer, nil
+}
+
+// writePair writes a PEM encoded certificate, and its private key in PKCS#8
+// format.
+func (s *store) writePair(certName, keyName string, der []byte, key crypto.Signer) error {
+	b, err := keys.MarshalPrivateKey(key, keys.PKCS8)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.path(keyName), pem.EncodeToMemory(b), 0o600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path(certName), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
+
+// nextSerial returns the serial number stored in the serial file, and
+// increments it.
+func (s *store) nextSerial() (*big.Int, error) {
+	return s.increment(serialFile)
+}
+
+// peekSerial returns the serial number stored in the serial file, without
+// incrementing it.
+func (s *store) peekSerial() (*big.Int, error) {
+	return s.number(serialFile)
+}
+
+// nextCRLNumber returns the CRL number stored in the crlnumber file, and
+// increments it.
+func (s *store) nextCRLNumber() (*big.Int, error) {
+	return s.increment(crlNumberFile)
+}
+
+func (s *store) number(name string) (*big.Int, error) {
+	b, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := new(big.Int).SetString(strings.TrimSpace(string(b)), 16)
+	if !ok {
+		return nil, fmt.Errorf("'%s' does not contain a hex encoded number", name)
+	}
+
+	return n, nil
+}
+
+func (s *store) increment(name string) (*big.Int, error) {
+	n, err := s.number(name)
+	if err != nil {
+		return nil, err
+	}
+
+	next := new(big.Int).Add(n, big.NewInt(1))
+
+	if err := os.WriteFile(s.path(name), []byte(formatSerial(next)+"\n"), 0o644); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s *store) index() ([]record, error) {
+	b, err := os.ReadFile(s.path(indexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, fmt.Errorf("error parsing '%s': %w", indexFile, err)
+	}
+
+	return records, nil
+}
+
+func (s *store) writeIndex(records []record) error {
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path(indexFile), append(b, '\n'), 0o644)
+}
+
+// addCertificate records an issued certificate in the index, and keeps a
+// copy of it in the certs directory.
+func (s *store) addCertificate(c *x509.Certificate) error {
+	records, err := s.index()
+	if err != nil {
+		return err
+	}
+
+	serial := formatSerial(c.SerialNumber)
+
+	records = append(records, record{
+		Serial:   serial,
+		Subject:  c.Subject.String(),
+		NotAfter: c.NotAfter,
+	})
+
+	if err := os.WriteFile(s.path(certsDir, serial+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}), 0o644); err != nil {
+		return err
+	}
+
+	return s.writeIndex(records)
+}
+
+// formatSerial returns n as upper case hex, padded to an even length.
+func formatSerial(n *big.Int) string {
+	h := strings.ToUpper(n.Text(16))
+	if len(h)%2 != 0 {
+		h = "0" + h
+	}
+
+	return h
+}
+
+// parseSerial parses a hex serial number, optionally separated by colons as
+// OpenSSL prints them.
+func parseSerial(s string) (*big.Int, error) {
+	s = strings.TrimPrefix(strings.ReplaceAll(s, ":", ""), "0x")
+
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid serial number '%s', expected hex", s)
+	}
+
+	return n, nil
+}
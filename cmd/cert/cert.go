@@ -0,0 +1,17 @@
+package cert
+
+import "github.com/spf13/cobra"
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert",
+		Short: "X.509 certificate related commands",
+	}
+
+	cmd.AddCommand(newSelfSignCommand())
+	cmd.AddCommand(newInspectCommand())
+	cmd.AddCommand(newVerifyCommand())
+	cmd.AddCommand(newScanCommand())
+
+	return cmd
+}
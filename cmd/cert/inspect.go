@@ -0,0 +1,88 @@
+package cert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newInspectCommand() *cobra.Command {
+	var (
+		str  string
+		file string
+	)
+
+	format := output.Text
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "inspect X.509 certificates, certificate signing requests and CRLs",
+		Long:  "inspect X.509 certificates, certificate signing requests and CRLs, from a PEM bundle, a DER file or a PKCS#7 certificate bundle",
+		Example: `
+    # Inspect a certificate
+    $ genc cert inspect --file domain.crt
+
+    # Inspect a certificate chain, as JSON
+    $ genc cert inspect --file chain.pem --output json
+
+    # Inspect a base64 encoded DER certificate
+    $ genc cert inspect --string "MIIDdzCCAl+gAwIBAgIE..."`,
+		Run: func(cmd *cobra.Command, args []string) {
+			in := []byte(str)
+
+			if file != "" {
+				var err error
+
+				in, err = os.ReadFile(file)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading file: %w", err))
+					os.Exit(1)
+				}
+			} else if !bytes.Contains(in, []byte("-----BEGIN")) {
+				var err error
+
+				in, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(str), ""))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error decoding base64 input: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			b, err := certs.Parse(in)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing input: %w", err))
+				os.Exit(1)
+			}
+
+			d := certs.Describe(b, time.Now())
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, d)
+			} else {
+				err = d.WriteText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	inspectCmd.Flags().StringVar(&str, "string", "", "the PEM or base64 encoded DER string to inspect")
+	inspectCmd.Flags().StringVar(&file, "file", "", "the location of the file on disk")
+	inspectCmd.Flags().Var(&format, "output", "the output format")
+
+	inspectCmd.MarkFlagsOneRequired("string", "file")
+	inspectCmd.MarkFlagsMutuallyExclusive("string", "file")
+
+	return inspectCmd
+}
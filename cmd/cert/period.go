@@ -0,0 +1,56 @@
+// period implements a custom type to be used with Cobra.
+//
+// It accepts a Go duration, such as 12h, or a number of days or weeks, such
+// as 30d or 2w.
+
+package cert
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type period time.Duration
+
+func (p *period) String() string {
+	d := time.Duration(*p)
+	if d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+
+	return d.String()
+}
+
+func (p *period) Set(v string) error {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+	for suffix, u := range units {
+		if !strings.HasSuffix(v, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(v, suffix))
+		if err != nil || n < 0 {
+			return errors.New(`must be a duration, such as 30d, 2w or 12h`)
+		}
+
+		*p = period(time.Duration(n) * u)
+
+		return nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return errors.New(`must be a duration, such as 30d, 2w or 12h`)
+	}
+
+	*p = period(d)
+
+	return nil
+}
+
+func (p *period) Type() string {
+	return "duration"
+}
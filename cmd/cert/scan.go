@@ -0,0 +1,62 @@
+package cert
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newScanCommand() *cobra.Command {
+	within := period(30 * 24 * time.Hour)
+	format := output.Text
+
+	scanCmd := &cobra.Command{
+		Use:   "scan <path>...",
+		Short: "find certificates that are expiring soon",
+		Long: `find certificates that are expiring soon
+
+Directories are walked recursively, and every certificate in PEM bundles, DER files and PKCS#7 certificate bundles is checked.
+Certificates that have expired, or expire within the --within period, are listed soonest first.
+
+The exit code is 1 if any certificates are expiring, or any files couldn't be parsed, so it can be used to gate CI pipelines.`,
+		Example: `
+    # Find certificates expiring in the next 30 days
+    $ genc cert scan ./certs
+
+    # Find certificates expiring in the next 2 weeks, as JSON
+    $ genc cert scan ./certs /etc/ssl/bundle.p7b --within 2w --output json`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			r, err := certs.Scan(args, time.Duration(within), time.Now())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error scanning for certificates: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, r)
+			} else {
+				err = r.WriteText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+
+			if len(r.Expiring) > 0 || len(r.Errors) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	scanCmd.Flags().Var(&within, "within", "report certificates expiring within this period, such as 30d, 2w or 12h")
+	scanCmd.Flags().Var(&format, "output", "the output format")
+
+	return scanCmd
+}
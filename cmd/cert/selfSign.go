@@ -0,0 +1,201 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+	"github.com/simondrake/genc/internal/output"
+)
+
+type selfSignOptions struct {
+	csr         []byte
+	subject     string
+	sans        []string
+	keyUsage    []string
+	extKeyUsage []string
+	days        int
+	ca          bool
+}
+
+func newSelfSignCommand() *cobra.Command {
+	var (
+		privateKey     string
+		passphraseFile string
+		csrFile        string
+		out            string
+		opts           selfSignOptions
+	)
+
+	selfSignCmd := &cobra.Command{
+		Use:   "self-sign",
+		Short: "create a self-signed certificate",
+		Long:  "create a self-signed certificate, either from a subject and subject alternative names, or from an existing certificate signing request",
+		Example: `
+    # Create a self-signed certificate, valid for a year
+    $ genc cert self-sign --private-key rsa.key --subject "/O=Wibble Wobble, Inc./CN=example.com" --san example.com --out domain.crt
+
+    # Create a self-signed certificate from a CSR
+    $ genc cert self-sign --private-key rsa.key --csr domain.csr --days 30 --out domain.crt
+
+    # Create a self-signed CA certificate
+    $ genc cert self-sign --private-key ca.key --subject "CN=Wibble Wobble Root CA" --ca --days 3650 --out ca.crt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := keys.LoadPrivateKey(privateKey, keys.Passphrase(passphraseFile))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error loading private key: %w", err))
+				os.Exit(1)
+			}
+
+			if csrFile != "" {
+				opts.csr, err = os.ReadFile(csrFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading csr: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			der, err := selfSign(key, opts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error creating certificate: %w", err))
+				os.Exit(1)
+			}
+
+			if err := output.WriteFile(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing certificate: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	selfSignCmd.Flags().StringVar(&privateKey, "private-key", "", "the location of the private key on disk")
+	selfSignCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
+	selfSignCmd.Flags().StringVar(&csrFile, "csr", "", "the location of a certificate signing request on disk")
+	selfSignCmd.Flags().StringVar(&opts.subject, "subject", "", "the subject, e.g. /C=GB/O=Wibble/CN=example.com or CN=example.com,O=Wibble")
+	selfSignCmd.Flags().StringSliceVar(&opts.sans, "san", nil, "subject alternative names")
+	selfSignCmd.Flags().StringSliceVar(&opts.keyUsage, "key-usage", nil, "key usages, e.g. digitalSignature,keyEncipherment")
+	selfSignCmd.Flags().StringSliceVar(&opts.extKeyUsage, "ext-key-usage", nil, "extended key usages, e.g. serverAuth,clientAuth")
+	selfSignCmd.Flags().IntVar(&opts.days, "days", 365, "the number of days the certificate is valid for")
+	selfSignCmd.Flags().BoolVar(&opts.ca, "ca", false, "whether the certificate is a certificate authority")
+	selfSignCmd.Flags().StringVar(&out, "out", "", "the location to write the certificate to, defaults to stdout")
+
+	if err := selfSignCmd.MarkFlagRequired("private-key"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'private-key' as required: %w", err))
+	}
+
+	selfSignCmd.MarkFlagsOneRequired("csr", "subject")
+
+	return selfSignCmd
+}
+
+func selfSign(key crypto.PrivateKey, opts selfSignOptions) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	tmpl, err := certs.NewTemplate(pkix.Name{}, time.Now().AddDate(0, 0, opts.days))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.csr) > 0 {
+		if err := applyCSR(tmpl, signer.Public(), opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.subject != "" {
+		if tmpl.Subject, err = certs.ParseSubject(opts.subject); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.sans) > 0 {
+		s, err := certs.ParseSANs(opts.sans)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl.DNSNames, tmpl.IPAddresses, tmpl.EmailAddresses, tmpl.URIs = s.DNSNames, s.IPAddresses, s.EmailAddresses, s.URIs
+	}
+
+	if tmpl.KeyUsage, err = certs.ParseKeyUsage(opts.keyUsage); err != nil {
+		return nil, err
+	}
+
+	if tmpl.ExtKeyUsage, err = certs.ParseExtKeyUsage(opts.extKeyUsage); err != nil {
+		return nil, err
+	}
+
+	if opts.ca {
+		tmpl.IsCA = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	return x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+}
+
+var (
+	oidExtensionSubjectAltName   = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage      = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+)
+
+// applyCSR copies the subject, subject alternative names and requested
+// extensions from a certificate signing request into tmpl.
+//
+// Requested key usages are only copied if they're not being overridden, and
+// basic constraints are always taken from the template.
+func applyCSR(tmpl *x509.Certificate, pub crypto.PublicKey, opts selfSignOptions) error {
+	der := opts.csr
+	if b, _ := pem.Decode(opts.csr); b != nil {
+		der = b.Bytes
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return fmt.Errorf("error parsing csr: %w", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return fmt.Errorf("error checking csr signature: %w", err)
+	}
+
+	if k, ok := csr.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(pub) {
+		return errors.New("csr public key does not match the private key")
+	}
+
+	tmpl.Subject = csr.Subject
+	tmpl.DNSNames = csr.DNSNames
+	tmpl.IPAddresses = csr.IPAddresses
+	tmpl.EmailAddresses = csr.EmailAddresses
+	tmpl.URIs = csr.URIs
+
+	for _, ext := range csr.Extensions {
+		switch {
+		case ext.Id.Equal(oidExtensionSubjectAltName), ext.Id.Equal(oidExtensionBasicConstraints):
+			continue
+		case ext.Id.Equal(oidExtensionKeyUsage) && (len(opts.keyUsage) > 0 || opts.ca):
+			continue
+		case ext.Id.Equal(oidExtensionExtKeyUsage) && len(opts.extKeyUsage) > 0:
+			continue
+		}
+
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+)
+
+func TestSelfSign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	t.Run("Subject", func(t *testing.T) {
+		der, err := selfSign(key, selfSignOptions{
+			subject:     "/C=GB/O=Wibble Wobble, Inc./CN=example.com",
+			sans:        []string{"example.com", "10.0.0.1"},
+			keyUsage:    []string{"digitalSignature"},
+			extKeyUsage: []string{"serverAuth"},
+			days:        30,
+		})
+		if err != nil {
+			t.Fatalf("selfSign returned an error when one wasn't expected: %+v", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		if cert.Subject.CommonName != "example.com" || cert.Subject.Organization[0] != "Wibble Wobble, Inc." {
+			t.Errorf("unexpected subject %q", cert.Subject)
+		}
+
+		if len(cert.DNSNames) != 1 || len(cert.IPAddresses) != 1 {
+			t.Errorf("unexpected subject alternative names %v %v", cert.DNSNames, cert.IPAddresses)
+		}
+
+		if cert.KeyUsage != x509.KeyUsageDigitalSignature || len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+			t.Errorf("unexpected key usages %v %v", cert.KeyUsage, cert.ExtKeyUsage)
+		}
+
+		if cert.IsCA {
+			t.Errorf("expected certificate not to be a CA")
+		}
+
+		if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+			t.Errorf("CheckSignature returned an error when one wasn't expected: %+v", err)
+		}
+	})
+
+	t.Run("CA", func(t *testing.T) {
+		der, err := selfSign(key, selfSignOptions{subject: "CN=Wibble Wobble Root CA", days: 365, ca: true})
+		if err != nil {
+			t.Fatalf("selfSign returned an error when one wasn't expected: %+v", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		if !cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+			t.Errorf("expected certificate to be a CA")
+		}
+	})
+
+	t.Run("CSR", func(t *testing.T) {
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: "wibble.example.com"},
+			DNSNames: []string{"wibble.example.com"},
+		}, key)
+		if err != nil {
+			t.Fatalf("CreateCertificateRequest returned an error when one wasn't expected: %+v", err)
+		}
+
+		der, err := selfSign(key, selfSignOptions{
+			csr:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}),
+			days: 1,
+		})
+		if err != nil {
+			t.Fatalf("selfSign returned an error when one wasn't expected: %+v", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		if cert.Subject.CommonName != "wibble.example.com" || len(cert.DNSNames) != 1 {
+			t.Errorf("expected subject and subject alternative names to be copied from the csr, got %q %v", cert.Subject, cert.DNSNames)
+		}
+
+		other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+		}
+
+		if _, err := selfSign(other, selfSignOptions{csr: csr, days: 1}); err == nil {
+			t.Errorf("expected an error when the csr doesn't match the private key")
+		}
+	})
+}
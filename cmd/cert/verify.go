@@ -0,0 +1,110 @@
+package cert
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newVerifyCommand() *cobra.Command {
+	var (
+		certFile          string
+		intermediatesFile string
+		rootsFile         string
+		at                string
+		extKeyUsage       []string
+	)
+
+	opts := certs.VerifyOptions{}
+	format := output.Text
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify a certificate chain",
+		Long:  "build and validate a certificate chain against a root bundle, checking the validity period, hostname and extended key usage, and reporting each reason the certificate is invalid",
+		Example: `
+    # Verify a certificate against the system roots
+    $ genc cert verify --cert domain.crt --hostname example.com
+
+    # Verify a client certificate issued by genc ca, as it would be in a year's time
+    $ genc cert verify --cert client.crt --intermediates ca/intermediate.crt --roots ca/root.crt --ext-key-usage clientAuth --time 2030-01-01T00:00:00Z
+
+    # The certificate file can contain the chain, the first certificate is verified
+    $ genc cert verify --cert chain.pem --roots ca.crt --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			chain, err := certs.LoadCertificates(certFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error loading certificate: %w", err))
+				os.Exit(1)
+			}
+
+			leaf := chain[0]
+			opts.Intermediates = chain[1:]
+
+			if intermediatesFile != "" {
+				ic, err := certs.LoadCertificates(intermediatesFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading intermediates: %w", err))
+					os.Exit(1)
+				}
+
+				opts.Intermediates = append(opts.Intermediates, ic...)
+			}
+
+			if rootsFile != "" {
+				if opts.Roots, err = certs.LoadPool(rootsFile); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading roots: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			if at != "" {
+				if opts.Time, err = time.Parse(time.RFC3339, at); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing time: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			if opts.ExtKeyUsages, err = certs.ParseExtKeyUsage(extKeyUsage); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing extended key usage: %w", err))
+				os.Exit(1)
+			}
+
+			v := certs.Verify(leaf, opts)
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, v)
+			} else {
+				err = v.WriteText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+
+			if !v.Valid {
+				os.Exit(1)
+			}
+		},
+	}
+
+	verifyCmd.Flags().StringVar(&certFile, "cert", "", "the location of the certificate on disk, followed by any intermediates")
+	verifyCmd.Flags().StringVar(&intermediatesFile, "intermediates", "", "the location of a bundle of intermediate certificates on disk")
+	verifyCmd.Flags().StringVar(&rootsFile, "roots", "", "the location of a bundle of trusted root certificates on disk, defaults to the system roots")
+	verifyCmd.Flags().StringVar(&at, "time", "", "the time to verify the certificate at, in RFC3339 format, defaults to now")
+	verifyCmd.Flags().StringVar(&opts.Hostname, "hostname", "", "the hostname, or IP address, the certificate should be valid for")
+	verifyCmd.Flags().StringSliceVar(&extKeyUsage, "ext-key-usage", nil, "extended key usages the certificate should be valid for, e.g. serverAuth")
+	verifyCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := verifyCmd.MarkFlagRequired("cert"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cert' as required: %w", err))
+	}
+
+	return verifyCmd
+}
@@ -0,0 +1,118 @@
+package cidr
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newAllocateCommand() *cobra.Command {
+	var (
+		parent   string
+		used     []string
+		usedFile string
+		sizes    []string
+	)
+
+	format := output.Text
+
+	allocateCmd := &cobra.Command{
+		Use:   "allocate",
+		Short: "find the next free, aligned subnet in a CIDR",
+		Long: `find the next free, aligned subnet in a CIDR that doesn't overlap any existing allocations
+
+If --size is repeated, the subnets are allocated in order, and each is treated as used by those that follow.`,
+		Example: `
+    $ genc cidr allocate --parent 10.0.0.0/16 --used 10.0.0.0/24 --used 10.0.2.0/23 --size /24
+    CIDR         FAMILY  FIRST     LAST        TOTAL HOSTS  USABLE HOSTS
+    10.0.1.0/24  IPv4    10.0.1.0  10.0.1.255  256          254
+
+    $ genc cidr allocate --parent 10.0.0.0/16 --used '["10.0.0.0/24", "10.0.2.0/23"]' --size /22 --size /26 --output json
+
+    $ genc cidr allocate --parent 2001:db8::/48 --used-file allocations.txt --size 64`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p, err := netaddr.ParsePrefix(parent)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing parent CIDR: %w", err))
+				os.Exit(1)
+			}
+
+			u, err := input.PrefixList(used, usedFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading used CIDRs: %w", err))
+				os.Exit(1)
+			}
+
+			prs, err := allocateCIDRs(p, u, sizes)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error allocating CIDR: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, prs)
+			} else {
+				err = writeParseTable(os.Stdout, prs)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	allocateCmd.Flags().StringVar(&parent, "parent", "", "the CIDR to allocate from")
+	allocateCmd.Flags().StringArrayVar(&used, "used", nil, "a CIDR, or JSON list of CIDRs, that is already allocated, can be repeated")
+	allocateCmd.Flags().StringVar(&usedFile, "used-file", "", "the location of a file on disk containing allocated CIDRs, one per line")
+	allocateCmd.Flags().StringSliceVar(&sizes, "size", nil, "the prefix length of the subnet to allocate, such as /24, can be repeated")
+	allocateCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := allocateCmd.MarkFlagRequired("parent"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'parent' as required: %w", err))
+	}
+	if err := allocateCmd.MarkFlagRequired("size"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'size' as required: %w", err))
+	}
+
+	return allocateCmd
+}
+
+// allocateCIDRs allocates a subnet of each of the sizes from parent in turn.
+func allocateCIDRs(parent netip.Prefix, used []netip.Prefix, sizes []string) ([]*parseResponse, error) {
+	var prs []*parseResponse
+
+	for _, s := range sizes {
+		bits, err := strconv.Atoi(strings.TrimPrefix(s, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid size '%s'", s)
+		}
+
+		p, ok, err := netaddr.Allocate(parent, used, bits)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("there is no free /%d in %s", bits, parent)
+		}
+
+		pr, err := parseCIDR(p.String())
+		if err != nil {
+			return nil, err
+		}
+
+		prs = append(prs, pr)
+		used = append(used, p)
+	}
+
+	return prs, nil
+}
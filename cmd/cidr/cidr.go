@@ -12,6 +12,14 @@ func NewCommand() *cobra.Command {
 
 	cmd.AddCommand(newOverlapCommand())
 	cmd.AddCommand(newParseCommand())
+	cmd.AddCommand(newMergeCommand())
+	cmd.AddCommand(newExcludeCommand())
+	cmd.AddCommand(newSplitCommand())
+	cmd.AddCommand(newAllocateCommand())
+	cmd.AddCommand(newFromRangeCommand())
+	cmd.AddCommand(newToRangeCommand())
+	cmd.AddCommand(newHostsCommand())
+	cmd.AddCommand(newExportCommand())
 
 	return cmd
 }
@@ -0,0 +1,109 @@
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newExcludeCommand() *cobra.Command {
+	var (
+		cidrs       string
+		file        string
+		exclude     []string
+		excludeFile string
+	)
+
+	format := output.Text
+
+	excludeCmd := &cobra.Command{
+		Use:   "exclude [cidr]...",
+		Short: "subtract CIDRs from a set of CIDRs",
+		Long: `subtract CIDRs from a set of CIDRs, and output the minimal list of CIDRs that remain
+
+CIDRs can be provided as arguments, as a JSON list with --cidrs, or one per line in a file or on stdin.
+The CIDRs to exclude can be repeated, provided as a JSON list, or one per line in a file.`,
+		Example: `
+    $ genc cidr exclude 10.0.0.0/8 --exclude 10.0.0.0/16 --exclude 10.128.0.0/9
+    10.1.0.0/16
+    10.2.0.0/15
+    10.4.0.0/14
+    10.8.0.0/13
+    10.16.0.0/12
+    10.32.0.0/11
+    10.64.0.0/10
+
+    $ genc cidr exclude --cidrs '["192.168.0.0/24", "2001:db8::/32"]' --exclude '["192.168.0.128/25", "2001:db8::/33"]'
+    192.168.0.0/25
+    2001:db8:8000::/33
+
+    $ genc cidr exclude --file vpcs.txt --exclude-file reserved.txt --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prefixes, err := input.Prefixes(args, cidrs, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
+				os.Exit(1)
+			}
+
+			if len(prefixes) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidrs or --file"))
+				os.Exit(1)
+			}
+
+			excluded, err := input.PrefixList(exclude, excludeFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs to exclude: %w", err))
+				os.Exit(1)
+			}
+
+			er := excludeCIDRs(prefixes, excluded)
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, er)
+			} else {
+				err = writePrefixes(os.Stdout, er.CIDRs)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	excludeCmd.Flags().StringVar(&cidrs, "cidrs", "", "the list of CIDRs to subtract from, as JSON")
+	excludeCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to subtract from, one per line, or - to read from stdin")
+	excludeCmd.Flags().StringArrayVar(&exclude, "exclude", nil, "a CIDR, or JSON list of CIDRs, to exclude, can be repeated")
+	excludeCmd.Flags().StringVar(&excludeFile, "exclude-file", "", "the location of a file on disk containing CIDRs to exclude, one per line")
+	excludeCmd.Flags().Var(&format, "output", "the output format")
+
+	excludeCmd.MarkFlagsOneRequired("exclude", "exclude-file")
+
+	return excludeCmd
+}
+
+type excludeResponse struct {
+	From    []netip.Prefix `json:"from"`
+	Exclude []netip.Prefix `json:"exclude"`
+	CIDRs   []netip.Prefix `json:"cidrs"`
+}
+
+func excludeCIDRs(prefixes, exclude []netip.Prefix) *excludeResponse {
+	remaining := netaddr.Exclude(prefixes, exclude)
+	if remaining == nil {
+		remaining = []netip.Prefix{}
+	}
+
+	return &excludeResponse{
+		From:    netaddr.Merge(prefixes),
+		Exclude: netaddr.Merge(exclude),
+		CIDRs:   remaining,
+	}
+}
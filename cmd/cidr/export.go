@@ -0,0 +1,385 @@
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newExportCommand() *cobra.Command {
+	var (
+		cidrs  string
+		file   string
+		format exportFormat
+		merge  bool
+		opts   exportOptions
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export [cidr]...",
+		Short: "generate firewall rules and access lists from CIDRs",
+		Long: `generate firewall rules and access lists from CIDRs
+
+CIDRs can be provided as arguments, as a JSON list with --cidrs, or one per line in a file or on stdin, and can be
+merged into the minimal equivalent set first with --merge.
+
+  nftables           named interval sets, one per address family, in the inet filter table, always merged
+  iptables           iptables and ip6tables commands that append a rule per CIDR to --chain
+  k8s-networkpolicy  a NetworkPolicy that allows ingress from an ipBlock per CIDR
+  nginx              allow directives followed by deny all, or deny directives with --deny
+  aws-sg             security group ingress IP permissions, for aws ec2 authorize-security-group-ingress`,
+		Example: `
+    $ genc cidr export 10.0.0.0/24 10.0.1.0/24 2001:db8::/32 --format nftables --name office --merge
+    table inet filter {
+    	set office_v4 {
+    		type ipv4_addr
+    		flags interval
+    		elements = { 10.0.0.0/23 }
+    	}
+    	set office_v6 {
+    		type ipv6_addr
+    		flags interval
+    		elements = { 2001:db8::/32 }
+    	}
+    }
+
+    $ genc cidr export 10.0.0.0/8 2001:db8::/32 --format iptables --port 443
+    iptables -A INPUT -s 10.0.0.0/8 -p tcp --dport 443 -m comment --comment genc -j ACCEPT
+    ip6tables -A INPUT -s 2001:db8::/32 -p tcp --dport 443 -m comment --comment genc -j ACCEPT
+
+    $ genc cidr export --file blocked.txt --format nginx --deny
+    # genc
+    deny 192.0.2.0/24;
+    deny 198.51.100.0/24;
+
+    $ genc cidr export --file partners.txt --format aws-sg --port 443 > permissions.json
+    $ aws ec2 authorize-security-group-ingress --group-id sg-0123456789abcdef0 --ip-permissions file://permissions.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prefixes, err := input.Prefixes(args, cidrs, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
+				os.Exit(1)
+			}
+
+			if len(prefixes) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidrs or --file"))
+				os.Exit(1)
+			}
+
+			if merge {
+				prefixes = netaddr.Merge(prefixes)
+			}
+
+			if err := exportCIDRs(os.Stdout, prefixes, format, opts); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error exporting CIDRs: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	exportCmd.Flags().StringVar(&cidrs, "cidrs", "", "the list of CIDRs to export, as JSON")
+	exportCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to export, one per line, or - to read from stdin")
+	exportCmd.Flags().Var(&format, "format", "the format to export the CIDRs in")
+	exportCmd.Flags().BoolVar(&merge, "merge", false, "merge overlapping and adjacent CIDRs before exporting them")
+	exportCmd.Flags().StringVar(&opts.name, "name", "genc", "the name of the set, policy or rules, used as the iptables comment and security group description; nftables, k8s-networkpolicy, nginx and aws-sg restrict the characters it can contain")
+	exportCmd.Flags().StringVar(&opts.chain, "chain", "INPUT", "the iptables chain to append rules to")
+	exportCmd.Flags().StringVar(&opts.protocol, "protocol", "", "the protocol of --port, tcp or udp, defaults to tcp, for iptables, k8s-networkpolicy and aws-sg")
+	exportCmd.Flags().IntVar(&opts.port, "port", 0, "the port to allow, or 0 for all ports and protocols, for iptables, k8s-networkpolicy and aws-sg")
+	exportCmd.Flags().BoolVar(&opts.deny, "deny", false, "deny the CIDRs rather than allow them, for iptables and nginx")
+
+	if err := exportCmd.MarkFlagRequired("format"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'format' as required: %w", err))
+	}
+
+	return exportCmd
+}
+
+type exportOptions struct {
+	name     string
+	chain    string
+	protocol string
+	port     int
+	deny     bool
+}
+
+// exportCIDRs writes prefixes to w in the format f.
+func exportCIDRs(w io.Writer, prefixes []netip.Prefix, f exportFormat, opts exportOptions) error {
+	if opts.deny && f != exportFormatIptables && f != exportFormatNginx {
+		return fmt.Errorf("--deny isn't supported by %s", f)
+	}
+
+	if f == exportFormatNftables || f == exportFormatNginx {
+		if opts.port != 0 {
+			return fmt.Errorf("--port isn't supported by %s", f)
+		}
+
+		if opts.protocol != "" {
+			return fmt.Errorf("--protocol isn't supported by %s", f)
+		}
+	}
+
+	// --protocol has no default, so that it can be rejected by the formats
+	// that don't support it
+	if opts.protocol == "" {
+		opts.protocol = "tcp"
+	}
+
+	if opts.protocol != "tcp" && opts.protocol != "udp" {
+		return fmt.Errorf("the protocol must be tcp or udp, not '%s'", opts.protocol)
+	}
+
+	if opts.port < 0 || opts.port > 65535 {
+		return fmt.Errorf("the port must be between 0 and 65535, not %d", opts.port)
+	}
+
+	if err := validateName(opts.name, f); err != nil {
+		return err
+	}
+
+	switch f {
+	case exportFormatNftables:
+		return exportNftables(w, prefixes, opts)
+	case exportFormatIptables:
+		return exportIptables(w, prefixes, opts)
+	case exportFormatNetworkPolicy:
+		return exportNetworkPolicy(w, prefixes, opts)
+	case exportFormatNginx:
+		return exportNginx(w, prefixes, opts)
+	case exportFormatAWS:
+		return exportAWS(w, prefixes, opts)
+	default:
+		return fmt.Errorf("unsupported format '%s'", f)
+	}
+}
+
+var (
+	// nftIdentifier matches the names nft accepts for a set, which are
+	// limited to 255 characters including the _v4 or _v6 suffix
+	nftIdentifier = regexp.MustCompile(`^[a-zA-Z_.][a-zA-Z0-9_.-]{0,251}$`)
+	// dns1123Subdomain matches the names Kubernetes accepts for most
+	// objects, which are limited to 253 characters
+	dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// awsDescription matches the descriptions AWS accepts for a security
+	// group rule's IP ranges
+	awsDescription = regexp.MustCompile(`^[a-zA-Z0-9. _\-:/()#,@\[\]+=&;{}!$*]{0,255}$`)
+)
+
+// validateName returns an error if name can't be used as is by f. iptables
+// quotes the name, so accepts any name.
+func validateName(name string, f exportFormat) error {
+	switch f {
+	case exportFormatNftables:
+		if !nftIdentifier.MatchString(name) {
+			return fmt.Errorf("--name must be an nftables identifier of up to 252 letters, digits, underscores, dots and hyphens, starting with a letter, underscore or dot, not '%s'", name)
+		}
+	case exportFormatNetworkPolicy:
+		if len(name) > 253 || !dns1123Subdomain.MatchString(name) {
+			return fmt.Errorf("--name must be a DNS-1123 subdomain of up to 253 lowercase letters, digits, hyphens and dots for a NetworkPolicy, not '%s'", name)
+		}
+	case exportFormatNginx:
+		// The name is written as a comment, so a newline would let it add
+		// directives of its own
+		if strings.IndexFunc(name, unicode.IsControl) != -1 {
+			return fmt.Errorf("--name can't contain newlines or other control characters for nginx, not %q", name)
+		}
+	case exportFormatAWS:
+		if !awsDescription.MatchString(name) {
+			return fmt.Errorf("--name must be up to 255 letters, digits, spaces and ._-:/()#,@[]+=&;{}!$* for a security group description, not %q", name)
+		}
+	}
+
+	return nil
+}
+
+// splitFamilies returns the IPv4 and IPv6 prefixes in prefixes.
+func splitFamilies(prefixes []netip.Prefix) (v4, v6 []netip.Prefix) {
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			v4 = append(v4, p)
+		} else {
+			v6 = append(v6, p)
+		}
+	}
+
+	return v4, v6
+}
+
+func exportNftables(w io.Writer, prefixes []netip.Prefix, opts exportOptions) error {
+	var sb strings.Builder
+
+	// nft refuses to load an interval set with overlapping elements, so the
+	// prefixes are always merged, even without --merge
+	v4, v6 := splitFamilies(netaddr.Merge(prefixes))
+
+	fmt.Fprintln(&sb, "table inet filter {")
+
+	for _, set := range []struct {
+		suffix   string
+		typ      string
+		prefixes []netip.Prefix
+	}{
+		{"v4", "ipv4_addr", v4},
+		{"v6", "ipv6_addr", v6},
+	} {
+		if len(set.prefixes) == 0 {
+			continue
+		}
+
+		elements := make([]string, len(set.prefixes))
+		for i, p := range set.prefixes {
+			elements[i] = p.String()
+		}
+
+		fmt.Fprintf(&sb, "\tset %s_%s {\n", opts.name, set.suffix)
+		fmt.Fprintf(&sb, "\t\ttype %s\n", set.typ)
+		fmt.Fprintln(&sb, "\t\tflags interval")
+		fmt.Fprintf(&sb, "\t\telements = { %s }\n", strings.Join(elements, ", "))
+		fmt.Fprintln(&sb, "\t}")
+	}
+
+	fmt.Fprintln(&sb, "}")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func exportIptables(w io.Writer, prefixes []netip.Prefix, opts exportOptions) error {
+	var sb strings.Builder
+
+	target := "ACCEPT"
+	if opts.deny {
+		target = "DROP"
+	}
+
+	for _, p := range prefixes {
+		command := "iptables"
+		if p.Addr().Is6() {
+			command = "ip6tables"
+		}
+
+		fmt.Fprintf(&sb, "%s -A %s -s %s", command, opts.chain, p)
+
+		if opts.port != 0 {
+			fmt.Fprintf(&sb, " -p %s --dport %d", opts.protocol, opts.port)
+		}
+
+		fmt.Fprintf(&sb, " -m comment --comment %s -j %s\n", shellQuote(opts.name), target)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// shellQuote returns s quoted for a POSIX shell, if it needs to be.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,:/") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func exportNetworkPolicy(w io.Writer, prefixes []netip.Prefix, opts exportOptions) error {
+	var sb strings.Builder
+
+	// The policy is written by hand, rather than with a YAML library, as its
+	// structure is fixed and every value is a name, CIDR or number
+	fmt.Fprintln(&sb, "apiVersion: networking.k8s.io/v1")
+	fmt.Fprintln(&sb, "kind: NetworkPolicy")
+	fmt.Fprintln(&sb, "metadata:")
+	fmt.Fprintf(&sb, "  name: %s\n", opts.name)
+	fmt.Fprintln(&sb, "spec:")
+	fmt.Fprintln(&sb, "  podSelector: {}")
+	fmt.Fprintln(&sb, "  policyTypes:")
+	fmt.Fprintln(&sb, "    - Ingress")
+	fmt.Fprintln(&sb, "  ingress:")
+	fmt.Fprintln(&sb, "    - from:")
+
+	for _, p := range prefixes {
+		fmt.Fprintln(&sb, "        - ipBlock:")
+		fmt.Fprintf(&sb, "            cidr: %s\n", p)
+	}
+
+	if opts.port != 0 {
+		fmt.Fprintln(&sb, "      ports:")
+		fmt.Fprintf(&sb, "        - protocol: %s\n", strings.ToUpper(opts.protocol))
+		fmt.Fprintf(&sb, "          port: %d\n", opts.port)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func exportNginx(w io.Writer, prefixes []netip.Prefix, opts exportOptions) error {
+	var sb strings.Builder
+
+	directive := "allow"
+	if opts.deny {
+		directive = "deny"
+	}
+
+	fmt.Fprintf(&sb, "# %s\n", opts.name)
+
+	for _, p := range prefixes {
+		fmt.Fprintf(&sb, "%s %s;\n", directive, p)
+	}
+
+	if !opts.deny {
+		fmt.Fprintln(&sb, "deny all;")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+type awsIPPermission struct {
+	IPProtocol string         `json:"IpProtocol"`
+	FromPort   int            `json:"FromPort,omitempty"`
+	ToPort     int            `json:"ToPort,omitempty"`
+	IPRanges   []awsIPRange   `json:"IpRanges,omitempty"`
+	IPv6Ranges []awsIPv6Range `json:"Ipv6Ranges,omitempty"`
+}
+
+type awsIPRange struct {
+	CidrIP      string `json:"CidrIp"`
+	Description string `json:"Description"`
+}
+
+type awsIPv6Range struct {
+	CidrIPv6    string `json:"CidrIpv6"`
+	Description string `json:"Description"`
+}
+
+func exportAWS(w io.Writer, prefixes []netip.Prefix, opts exportOptions) error {
+	// A protocol of -1 allows all protocols and ports
+	perm := awsIPPermission{IPProtocol: "-1"}
+
+	if opts.port != 0 {
+		perm = awsIPPermission{IPProtocol: opts.protocol, FromPort: opts.port, ToPort: opts.port}
+	}
+
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			perm.IPRanges = append(perm.IPRanges, awsIPRange{CidrIP: p.String(), Description: opts.name})
+		} else {
+			perm.IPv6Ranges = append(perm.IPv6Ranges, awsIPv6Range{CidrIPv6: p.String(), Description: opts.name})
+		}
+	}
+
+	return output.WriteJSON(w, []awsIPPermission{perm})
+}
@@ -0,0 +1,36 @@
+// exportFormat implements a custom type to be used with Cobra.
+//
+// It ensures that the export format is one of nftables, iptables,
+// k8s-networkpolicy, nginx, or aws-sg.
+
+package cidr
+
+import "errors"
+
+type exportFormat string
+
+const (
+	exportFormatNftables      exportFormat = "nftables"
+	exportFormatIptables      exportFormat = "iptables"
+	exportFormatNetworkPolicy exportFormat = "k8s-networkpolicy"
+	exportFormatNginx         exportFormat = "nginx"
+	exportFormatAWS           exportFormat = "aws-sg"
+)
+
+func (f *exportFormat) String() string {
+	return string(*f)
+}
+
+func (f *exportFormat) Set(v string) error {
+	switch exportFormat(v) {
+	case exportFormatNftables, exportFormatIptables, exportFormatNetworkPolicy, exportFormatNginx, exportFormatAWS:
+		*f = exportFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of nftables, iptables, k8s-networkpolicy, nginx, or aws-sg`)
+	}
+}
+
+func (f *exportFormat) Type() string {
+	return "[nftables,iptables,k8s-networkpolicy,nginx,aws-sg]"
+}
@@ -0,0 +1,166 @@
+package cidr
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestExportCIDRs(t *testing.T) {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+	}
+
+	tests := []struct {
+		name   string
+		format exportFormat
+		opts   exportOptions
+		// extra prefixes are exported after prefixes
+		extra    []netip.Prefix
+		expected string
+	}{
+		{
+			name:   "Nftables",
+			format: exportFormatNftables,
+			opts:   exportOptions{name: "office"},
+			expected: "table inet filter {\n" +
+				"\tset office_v4 {\n\t\ttype ipv4_addr\n\t\tflags interval\n\t\telements = { 10.0.0.0/8, 192.168.0.0/16 }\n\t}\n" +
+				"\tset office_v6 {\n\t\ttype ipv6_addr\n\t\tflags interval\n\t\telements = { 2001:db8::/32 }\n\t}\n" +
+				"}\n",
+		},
+		{
+			name:   "NftablesOverlapping",
+			format: exportFormatNftables,
+			opts:   exportOptions{name: "office"},
+			extra:  []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16"), netip.MustParsePrefix("192.169.0.0/16")},
+			expected: "table inet filter {\n" +
+				"\tset office_v4 {\n\t\ttype ipv4_addr\n\t\tflags interval\n\t\telements = { 10.0.0.0/8, 192.168.0.0/15 }\n\t}\n" +
+				"\tset office_v6 {\n\t\ttype ipv6_addr\n\t\tflags interval\n\t\telements = { 2001:db8::/32 }\n\t}\n" +
+				"}\n",
+		},
+		{
+			name:   "Iptables",
+			format: exportFormatIptables,
+			opts:   exportOptions{name: "office vpn", chain: "INPUT", port: 443},
+			expected: "iptables -A INPUT -s 10.0.0.0/8 -p tcp --dport 443 -m comment --comment 'office vpn' -j ACCEPT\n" +
+				"ip6tables -A INPUT -s 2001:db8::/32 -p tcp --dport 443 -m comment --comment 'office vpn' -j ACCEPT\n" +
+				"iptables -A INPUT -s 192.168.0.0/16 -p tcp --dport 443 -m comment --comment 'office vpn' -j ACCEPT\n",
+		},
+		{
+			name:   "IptablesDeny",
+			format: exportFormatIptables,
+			opts:   exportOptions{name: "genc", chain: "FORWARD", deny: true},
+			expected: "iptables -A FORWARD -s 10.0.0.0/8 -m comment --comment genc -j DROP\n" +
+				"ip6tables -A FORWARD -s 2001:db8::/32 -m comment --comment genc -j DROP\n" +
+				"iptables -A FORWARD -s 192.168.0.0/16 -m comment --comment genc -j DROP\n",
+		},
+		{
+			name:   "NetworkPolicy",
+			format: exportFormatNetworkPolicy,
+			opts:   exportOptions{name: "office", port: 53, protocol: "udp"},
+			expected: "apiVersion: networking.k8s.io/v1\nkind: NetworkPolicy\nmetadata:\n  name: office\n" +
+				"spec:\n  podSelector: {}\n  policyTypes:\n    - Ingress\n  ingress:\n    - from:\n" +
+				"        - ipBlock:\n            cidr: 10.0.0.0/8\n" +
+				"        - ipBlock:\n            cidr: 2001:db8::/32\n" +
+				"        - ipBlock:\n            cidr: 192.168.0.0/16\n" +
+				"      ports:\n        - protocol: UDP\n          port: 53\n",
+		},
+		{
+			name:     "Nginx",
+			format:   exportFormatNginx,
+			opts:     exportOptions{name: "office"},
+			expected: "# office\nallow 10.0.0.0/8;\nallow 2001:db8::/32;\nallow 192.168.0.0/16;\ndeny all;\n",
+		},
+		{
+			name:     "NginxDeny",
+			format:   exportFormatNginx,
+			opts:     exportOptions{name: "office", deny: true},
+			expected: "# office\ndeny 10.0.0.0/8;\ndeny 2001:db8::/32;\ndeny 192.168.0.0/16;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+
+			if err := exportCIDRs(&sb, append(append([]netip.Prefix(nil), prefixes...), tt.extra...), tt.format, tt.opts); err != nil {
+				t.Fatalf("exportCIDRs returned an error when one wasn't expected: %+v", err)
+			}
+
+			if sb.String() != tt.expected {
+				t.Errorf("expected:\n%s\nbut got:\n%s", tt.expected, sb.String())
+			}
+		})
+	}
+
+	t.Run("AWS", func(t *testing.T) {
+		for _, tt := range []struct {
+			port     int
+			protocol string
+			fromPort int
+		}{
+			{0, "-1", 0},
+			{443, "tcp", 443},
+		} {
+			var sb strings.Builder
+
+			if err := exportCIDRs(&sb, prefixes, exportFormatAWS, exportOptions{name: "office", protocol: "tcp", port: tt.port}); err != nil {
+				t.Fatalf("exportCIDRs returned an error when one wasn't expected: %+v", err)
+			}
+
+			var perms []awsIPPermission
+
+			if err := json.Unmarshal([]byte(sb.String()), &perms); err != nil {
+				t.Fatalf("unable to unmarshal output: %+v", err)
+			}
+
+			if len(perms) != 1 {
+				t.Fatalf("expected 1 permission but got %d", len(perms))
+			}
+
+			p := perms[0]
+
+			if p.IPProtocol != tt.protocol || p.FromPort != tt.fromPort || p.ToPort != tt.fromPort {
+				t.Errorf("expected protocol %s and ports %d but got %s and %d-%d", tt.protocol, tt.fromPort, p.IPProtocol, p.FromPort, p.ToPort)
+			}
+
+			if len(p.IPRanges) != 2 || p.IPRanges[1].CidrIP != "192.168.0.0/16" || p.IPRanges[1].Description != "office" {
+				t.Errorf("unexpected IPv4 ranges %+v", p.IPRanges)
+			}
+
+			if len(p.IPv6Ranges) != 1 || p.IPv6Ranges[0].CidrIPv6 != "2001:db8::/32" {
+				t.Errorf("unexpected IPv6 ranges %+v", p.IPv6Ranges)
+			}
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			format exportFormat
+			opts   exportOptions
+		}{
+			{"Protocol", exportFormatIptables, exportOptions{protocol: "icmp", port: 1}},
+			{"Port", exportFormatIptables, exportOptions{protocol: "tcp", port: 65536}},
+			{"Deny", exportFormatAWS, exportOptions{protocol: "tcp", deny: true}},
+			{"NftablesName", exportFormatNftables, exportOptions{name: "my office"}},
+			{"NftablesNameLength", exportFormatNftables, exportOptions{name: strings.Repeat("a", 253)}},
+			{"NetworkPolicyName", exportFormatNetworkPolicy, exportOptions{name: "Office: VPN"}},
+			{"NetworkPolicyNameHyphen", exportFormatNetworkPolicy, exportOptions{name: "office-"}},
+			{"NginxName", exportFormatNginx, exportOptions{name: "x\nallow all;\n#"}},
+			{"AWSName", exportFormatAWS, exportOptions{name: "office <vpn>"}},
+			{"AWSNameLength", exportFormatAWS, exportOptions{name: strings.Repeat("a", 256)}},
+			{"NftablesPort", exportFormatNftables, exportOptions{name: "office", port: 443}},
+			{"NftablesProtocol", exportFormatNftables, exportOptions{name: "office", protocol: "tcp"}},
+			{"NginxPort", exportFormatNginx, exportOptions{name: "office", port: 443}},
+			{"NginxProtocol", exportFormatNginx, exportOptions{name: "office", protocol: "udp"}},
+		} {
+			if err := exportCIDRs(&strings.Builder{}, prefixes, tt.format, tt.opts); err == nil {
+				t.Errorf("%s: exportCIDRs didn't return an error when one was expected", tt.name)
+			}
+		}
+	})
+}
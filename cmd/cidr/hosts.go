@@ -0,0 +1,197 @@
+package cidr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/netaddr"
+)
+
+// maxShuffle is the largest number of addresses that are shuffled when
+// sampling at random without a limit, as every index is held in memory.
+const maxShuffle = 1 << 20
+
+func newHostsCommand() *cobra.Command {
+	var (
+		cidr string
+		opts hostsOptions
+	)
+
+	hostsCmd := &cobra.Command{
+		Use:   "hosts",
+		Short: "list the addresses in a CIDR",
+		Long: `list the addresses in a CIDR, one per line
+
+Addresses are written as they are generated, so even the largest IPv6 prefixes can be listed, or sampled with --random,
+without being held in memory.`,
+		Example: `
+    $ genc cidr hosts --cidr 192.168.1.0/30
+    192.168.1.0
+    192.168.1.1
+    192.168.1.2
+    192.168.1.3
+
+    # Skip the network and broadcast addresses
+    $ genc cidr hosts --cidr 192.168.1.0/30 --usable
+    192.168.1.1
+    192.168.1.2
+
+    $ genc cidr hosts --cidr 2001:db8::/32 --offset 65536 --limit 2
+    2001:db8::1:0
+    2001:db8::1:1
+
+    # Sample 3 addresses at random, without repeats
+    $ genc cidr hosts --cidr 10.0.0.0/8 --random --limit 3 --seed 1
+    10.203.4.66
+    10.32.155.142
+    10.181.11.2`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p, err := netaddr.ParsePrefix(cidr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing CIDR '%s': %w", cidr, err))
+				os.Exit(1)
+			}
+
+			if !cmd.Flags().Changed("seed") {
+				opts.seed = time.Now().UnixNano()
+			}
+
+			w := bufio.NewWriter(os.Stdout)
+
+			if err := writeHosts(w, p, opts); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error listing hosts: %w", err))
+				os.Exit(1)
+			}
+
+			if err := w.Flush(); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	hostsCmd.Flags().StringVar(&cidr, "cidr", "", "the CIDR to list the addresses of")
+	hostsCmd.Flags().BoolVar(&opts.usable, "usable", false, "only list usable hosts, excluding the IPv4 network and broadcast addresses")
+	hostsCmd.Flags().Uint64Var(&opts.limit, "limit", 0, "the maximum number of addresses to list, or 0 for no limit")
+	hostsCmd.Flags().Uint64Var(&opts.offset, "offset", 0, "the number of addresses to skip")
+	hostsCmd.Flags().BoolVar(&opts.random, "random", false, "list addresses in a random order, without repeats")
+	hostsCmd.Flags().Int64Var(&opts.seed, "seed", 0, "the seed used with --random, for a repeatable sample")
+
+	if err := hostsCmd.MarkFlagRequired("cidr"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cidr' as required: %w", err))
+	}
+
+	hostsCmd.MarkFlagsMutuallyExclusive("random", "offset")
+
+	return hostsCmd
+}
+
+type hostsOptions struct {
+	usable bool
+	limit  uint64
+	offset uint64
+	random bool
+	seed   int64
+}
+
+// writeHosts writes the addresses in p to w, one per line, as set by opts.
+func writeHosts(w io.Writer, p netip.Prefix, opts hostsOptions) error {
+	first, last := p.Addr(), netaddr.Last(p)
+	size := netaddr.Size(p)
+
+	if opts.usable {
+		first, last, size = usableHosts(p)
+	}
+
+	if opts.random {
+		return writeRandomHosts(w, first, size, opts)
+	}
+
+	a, err := netaddr.FromInt(new(big.Int).Add(netaddr.ToInt(first), new(big.Int).SetUint64(opts.offset)), first.Is6())
+	if err != nil || last.Less(a) {
+		// The offset is past the end of the prefix
+		return nil
+	}
+
+	for n := uint64(0); opts.limit == 0 || n < opts.limit; n++ {
+		if _, err := fmt.Fprintln(w, a); err != nil {
+			return err
+		}
+
+		if a == last {
+			break
+		}
+
+		a = a.Next()
+	}
+
+	return nil
+}
+
+// writeRandomHosts writes opts.limit addresses, chosen at random from the size
+// addresses starting at first, to w. Small ranges are shuffled, while larger
+// ranges are sampled, skipping the addresses that have already been chosen.
+func writeRandomHosts(w io.Writer, first netip.Addr, size *big.Int, opts hostsOptions) error {
+	r := rand.New(rand.NewSource(opts.seed))
+	start := netaddr.ToInt(first)
+
+	write := func(i *big.Int) error {
+		a, err := netaddr.FromInt(i.Add(i, start), first.Is6())
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, a)
+
+		return err
+	}
+
+	limit := new(big.Int).SetUint64(opts.limit)
+	if opts.limit == 0 || limit.Cmp(size) > 0 {
+		limit.Set(size)
+	}
+
+	if size.IsInt64() && size.Int64() <= maxShuffle {
+		for _, i := range r.Perm(int(size.Int64()))[:limit.Int64()] {
+			if err := write(big.NewInt(int64(i))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if opts.limit == 0 {
+		return fmt.Errorf("--limit is required to sample more than %d addresses at random", maxShuffle)
+	}
+
+	if limit.Cmp(new(big.Int).Rsh(size, 1)) > 0 {
+		return fmt.Errorf("--limit must be no more than half of the %s addresses when sampling at random", size)
+	}
+
+	seen := make(map[string]struct{})
+
+	for uint64(len(seen)) < opts.limit {
+		i := new(big.Int).Rand(r, size)
+
+		if _, ok := seen[i.String()]; ok {
+			continue
+		}
+
+		seen[i.String()] = struct{}{}
+
+		if err := write(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
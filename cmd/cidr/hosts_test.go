@@ -0,0 +1,79 @@
+package cidr
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestWriteHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidr  string
+		opts  hostsOptions
+		hosts []string
+	}{
+		{"All", "192.168.1.0/30", hostsOptions{}, []string{"192.168.1.0", "192.168.1.1", "192.168.1.2", "192.168.1.3"}},
+		{"Usable", "192.168.1.0/30", hostsOptions{usable: true}, []string{"192.168.1.1", "192.168.1.2"}},
+		{"PointToPoint", "10.0.0.0/31", hostsOptions{usable: true}, []string{"10.0.0.0", "10.0.0.1"}},
+		{"OffsetAndLimit", "2001:db8::/32", hostsOptions{offset: 65536, limit: 2}, []string{"2001:db8::1:0", "2001:db8::1:1"}},
+		{"OffsetPastEnd", "10.0.0.0/30", hostsOptions{offset: 4}, nil},
+		{"EndOfAddressSpace", "::/0", hostsOptions{offset: 1<<64 - 1, limit: 1}, []string{"::ffff:ffff:ffff:ffff"}},
+		{"LastAddress", "255.255.255.254/31", hostsOptions{offset: 1}, []string{"255.255.255.255"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+
+			if err := writeHosts(&sb, netip.MustParsePrefix(tt.cidr), tt.opts); err != nil {
+				t.Fatalf("writeHosts returned an error when one wasn't expected: %+v", err)
+			}
+
+			if got := strings.Fields(sb.String()); strings.Join(got, ",") != strings.Join(tt.hosts, ",") {
+				t.Errorf("expected %v but got %v", tt.hosts, got)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		for _, tt := range []struct {
+			cidr  string
+			limit uint64
+			count int
+		}{
+			{"10.0.0.0/28", 0, 14},
+			{"10.0.0.0/28", 5, 5},
+			{"2001:db8::/32", 1000, 1000},
+		} {
+			var sb strings.Builder
+
+			p := netip.MustParsePrefix(tt.cidr)
+
+			if err := writeHosts(&sb, p, hostsOptions{usable: true, random: true, limit: tt.limit, seed: 1}); err != nil {
+				t.Fatalf("writeHosts returned an error when one wasn't expected: %+v", err)
+			}
+
+			seen := make(map[string]bool)
+
+			for _, h := range strings.Fields(sb.String()) {
+				a := netip.MustParseAddr(h)
+				if seen[h] || !p.Contains(a) || (a.Is4() && (a == p.Addr() || a == netip.MustParseAddr("10.0.0.15"))) {
+					t.Fatalf("%s was repeated, or isn't a usable host in %s", h, p)
+				}
+
+				seen[h] = true
+			}
+
+			if len(seen) != tt.count {
+				t.Errorf("expected %d hosts but got %d", tt.count, len(seen))
+			}
+		}
+	})
+
+	t.Run("RandomWithoutLimit", func(t *testing.T) {
+		if err := writeHosts(&strings.Builder{}, netip.MustParsePrefix("10.0.0.0/8"), hostsOptions{random: true}); err == nil {
+			t.Errorf("writeHosts was expected to return an error but didn't")
+		}
+	})
+}
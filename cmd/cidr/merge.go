@@ -0,0 +1,105 @@
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newMergeCommand() *cobra.Command {
+	var (
+		cidrs string
+		file  string
+	)
+
+	format := output.Text
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge [cidr]...",
+		Short: "merge overlapping and adjacent CIDRs into the minimal equivalent set",
+		Long: `merge overlapping and adjacent CIDRs into the minimal equivalent set
+
+CIDRs can be provided as arguments, as a JSON list with --cidrs, or one per line in a file or on stdin.
+The merged CIDRs are written one per line, IPv4 before IPv6, with a summary written to stderr.`,
+		Example: `
+    $ genc cidr merge 10.0.0.0/24 10.0.1.0/24 10.0.0.128/25 2001:db8::/33 2001:db8:8000::/33
+    10.0.0.0/23
+    2001:db8::/32
+    merged 5 CIDRs into 2
+
+    $ genc cidr merge --cidrs '["87.243.24.122/32", "87.243.24.0/24"]'
+    87.243.24.0/24
+    merged 2 CIDRs into 1
+
+    $ cat allowlist.txt | genc cidr merge --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prefixes, err := input.Prefixes(args, cidrs, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
+				os.Exit(1)
+			}
+
+			if len(prefixes) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidrs or --file"))
+				os.Exit(1)
+			}
+
+			mr := mergeCIDRs(prefixes)
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, mr)
+			} else {
+				err = writePrefixes(os.Stdout, mr.CIDRs)
+				fmt.Fprintf(os.Stderr, "merged %d CIDRs into %d\n", mr.Input, mr.Output)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	mergeCmd.Flags().StringVar(&cidrs, "cidrs", "", "the list of CIDRs to merge, as JSON")
+	mergeCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to merge, one per line, or - to read from stdin")
+	mergeCmd.Flags().Var(&format, "output", "the output format")
+
+	return mergeCmd
+}
+
+type mergeResponse struct {
+	Input     int            `json:"input"`
+	Output    int            `json:"output"`
+	Collapsed int            `json:"collapsed"`
+	CIDRs     []netip.Prefix `json:"cidrs"`
+}
+
+func mergeCIDRs(prefixes []netip.Prefix) *mergeResponse {
+	merged := netaddr.Merge(prefixes)
+
+	return &mergeResponse{
+		Input:     len(prefixes),
+		Output:    len(merged),
+		Collapsed: len(prefixes) - len(merged),
+		CIDRs:     merged,
+	}
+}
+
+// writePrefixes writes prefixes to w, one per line.
+func writePrefixes(w io.Writer, prefixes []netip.Prefix) error {
+	for _, p := range prefixes {
+		if _, err := fmt.Fprintln(w, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,92 +1,281 @@
 package cidr
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
+	"io"
+	"net/netip"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
 )
 
 func newOverlapCommand() *cobra.Command {
-	var cidrs string
+	var (
+		cidrs string
+		file  string
+		sets  []setArg
+	)
+
+	format := output.Text
 
 	overlapCmd := &cobra.Command{
-		Use:   "overlap",
+		Use:   "overlap [cidr]...",
 		Short: "determine if CIDR blocks overlap with each other",
+		Long: `determine if CIDR blocks overlap with each other, or with the CIDR blocks in another set
+
+CIDRs can be provided as arguments, as a JSON list with --cidrs, or one per line in a file or on stdin, in which case
+every pair of CIDRs in the list is compared. CIDRs with host bits set, such as 192.168.1.5/24, are compared as the block
+they are in.
+
+Alternatively, two named sets can be compared with --set and --set-file, in which case each CIDR in the first set is
+compared with each CIDR in the second, and the relationship between the two sets as a whole is reported.`,
 		Example: `
     $ genc cidr overlap --cidrs '["87.243.24.122/32", "87.243.24.0/24"]'
     CIDRs (87.243.24.122/32) and (87.243.24.0/24) overlap
 
     $ genc cidr overlap --cidrs '["87.243.24.122/32", "87.243.25.0/24"]'
-    CIDRs do not overlap`,
+    CIDRs do not overlap
+
+    $ genc cidr overlap --set ours='["10.0.0.0/16", "10.1.0.0/16"]' --set partners='["10.0.128.0/17", "10.1.2.0/24", "10.2.0.0/16"]'
+    OURS         PARTNERS       RELATIONSHIP  INTERSECTION
+    10.0.0.0/16  10.0.128.0/17  contains      10.0.128.0 - 10.0.255.255
+    10.1.0.0/16  10.1.2.0/24    contains      10.1.2.0 - 10.1.2.255
+
+    ours and partners partially overlap
+
+    $ genc cidr overlap --set-file ours=vpcs.txt --set-file partners=partner-vpcs.txt --output json`,
 		Run: func(cmd *cobra.Command, args []string) {
-			var c []string
+			if len(sets) > 0 {
+				named, err := readSets(sets)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading sets: %w", err))
+					os.Exit(1)
+				}
+
+				or := compareSets(named[0], named[1])
+
+				if format == output.JSON {
+					err = output.WriteJSON(os.Stdout, or)
+				} else {
+					err = or.writeText(os.Stdout)
+				}
+
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+					os.Exit(1)
+				}
 
-			if err := json.Unmarshal([]byte(cidrs), &c); err != nil {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing cidrs: %w", err))
+				return
+			}
+
+			prefixes, err := input.HostPrefixes(args, cidrs, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
 				os.Exit(1)
 			}
 
-			if err := doCIDRsOverlap(c); err != nil {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("error determining if CIDRs overlap: %w", err))
+			if len(prefixes) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidrs or --file"))
+				os.Exit(1)
+			}
+
+			or := doCIDRsOverlap(prefixes)
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, or)
+			} else {
+				err = or.writeText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
 				os.Exit(1)
 			}
 		},
 	}
 
 	overlapCmd.Flags().StringVar(&cidrs, "cidrs", "", "the list of CIDRs to check for overlap")
+	overlapCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to check for overlap, one per line, or - to read from stdin")
+	overlapCmd.Flags().Var(&setFlag{args: &sets}, "set", "a named set of CIDRs to compare, as name=JSON list, can be repeated")
+	overlapCmd.Flags().Var(&setFlag{args: &sets, fromFile: true}, "set-file", "a named set of CIDRs to compare, as name=path to a file on disk containing CIDRs, one per line, can be repeated")
+	overlapCmd.Flags().Var(&format, "output", "the output format")
 
-	if err := overlapCmd.MarkFlagRequired("cidrs"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cidrs' as required: %w", err))
-	}
+	overlapCmd.MarkFlagsMutuallyExclusive("cidrs", "set")
+	overlapCmd.MarkFlagsMutuallyExclusive("cidrs", "set-file")
+	overlapCmd.MarkFlagsMutuallyExclusive("file", "set")
+	overlapCmd.MarkFlagsMutuallyExclusive("file", "set-file")
 
 	return overlapCmd
 }
 
-func doCIDRsOverlap(cidrs []string) error {
-	var overlaps bool
+type namedSet struct {
+	name     string
+	prefixes []netip.Prefix
+}
 
-	// This map is a crufty way of ensuring that we don't print out the same
-	// CIDR overlap twice (as we loop through each thing twice)
-	//
-	// Should find a more elegant way of doing this, but it'll do for now
-	processed := make(map[string]struct{})
+// readSets parses the name=JSON list and name=path values in sets, keeping
+// the order they were provided in. Exactly two sets are required.
+func readSets(sets []setArg) ([]namedSet, error) {
+	var named []namedSet
 
-	for i1, c1 := range cidrs {
-		_, n1, err := net.ParseCIDR(c1)
-		if err != nil {
-			return err
+	for _, sa := range sets {
+		name, value, found := strings.Cut(sa.value, "=")
+		if !found || name == "" || value == "" {
+			return nil, fmt.Errorf("'%s' must be in the form name=value", sa.value)
 		}
 
-		for i2, c2 := range cidrs {
-			if i1 == i2 {
-				continue
+		for _, ns := range named {
+			if ns.name == name {
+				return nil, fmt.Errorf("the set '%s' was provided more than once", name)
 			}
+		}
 
-			_, n2, err := net.ParseCIDR(c2)
-			if err != nil {
+		var (
+			prefixes []netip.Prefix
+			err      error
+		)
+
+		if sa.fromFile {
+			prefixes, err = input.HostPrefixList(nil, value)
+		} else {
+			prefixes, err = input.ParseHostPrefixes([]string{value})
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading set '%s': %w", name, err)
+		}
+
+		named = append(named, namedSet{name: name, prefixes: dedupe(prefixes)})
+	}
+
+	if len(named) != 2 {
+		return nil, fmt.Errorf("exactly two sets are required, but %d were provided", len(named))
+	}
+
+	return named, nil
+}
+
+// dedupe returns prefixes without any that cover the same block as an
+// earlier prefix, so that each is only compared once.
+func dedupe(prefixes []netip.Prefix) []netip.Prefix {
+	seen := make(map[netip.Prefix]bool, len(prefixes))
+
+	var unique []netip.Prefix
+
+	for _, p := range prefixes {
+		if !seen[p.Masked()] {
+			seen[p.Masked()] = true
+			unique = append(unique, p)
+		}
+	}
+
+	return unique
+}
+
+type overlapPair struct {
+	A            netip.Prefix         `json:"a"`
+	B            netip.Prefix         `json:"b"`
+	Relationship netaddr.Relationship `json:"relationship"`
+	Intersection netaddr.Range        `json:"intersection"`
+}
+
+type overlapResponse struct {
+	// Sets, Relationship and Intersection are only set when comparing two
+	// named sets, in which case A is from the first set and B the second
+	Sets         []string             `json:"sets,omitempty"`
+	Relationship netaddr.Relationship `json:"relationship,omitempty"`
+	Intersection []netip.Prefix       `json:"intersection,omitempty"`
+	Overlaps     []overlapPair        `json:"overlaps"`
+}
+
+func (or *overlapResponse) writeText(w io.Writer) error {
+	if len(or.Sets) == 0 {
+		if len(or.Overlaps) == 0 {
+			_, err := fmt.Fprintln(w, "CIDRs do not overlap")
+			return err
+		}
+
+		for _, op := range or.Overlaps {
+			if _, err := fmt.Fprintf(w, "CIDRs (%s) and (%s) overlap\n", op.A, op.B); err != nil {
 				return err
 			}
+		}
 
-			_, c1e := processed[c1+c2]
-			_, c2e := processed[c2+c1]
+		return nil
+	}
 
-			if !c1e && !c2e && (n1.Contains(n2.IP) || n2.Contains(n1.IP)) {
-				fmt.Fprintf(os.Stdout, "CIDRs (%s) and (%s) overlap\n", c1, c2)
+	a, b := or.Sets[0], or.Sets[1]
 
-				processed[c1+c2] = struct{}{}
-				processed[c2+c1] = struct{}{}
+	if len(or.Overlaps) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
-				overlaps = true
-			}
+		fmt.Fprintf(tw, "%s\t%s\tRELATIONSHIP\tINTERSECTION\n", strings.ToUpper(a), strings.ToUpper(b))
+
+		for _, op := range or.Overlaps {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s - %s\n", op.A, op.B, op.Relationship, op.Intersection.From, op.Intersection.To)
 		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	var err error
+
+	switch or.Relationship {
+	case netaddr.Equal:
+		_, err = fmt.Fprintf(w, "%s and %s cover the same addresses\n", a, b)
+	case netaddr.Contains:
+		_, err = fmt.Fprintf(w, "%s contains %s\n", a, b)
+	case netaddr.ContainedBy:
+		_, err = fmt.Fprintf(w, "%s is contained by %s\n", a, b)
+	case netaddr.Partial:
+		_, err = fmt.Fprintf(w, "%s and %s partially overlap\n", a, b)
+	default:
+		_, err = fmt.Fprintf(w, "%s and %s do not overlap\n", a, b)
+	}
+
+	return err
+}
+
+// doCIDRsOverlap returns every pair of CIDRs in cidrs that overlap.
+func doCIDRsOverlap(cidrs []netip.Prefix) *overlapResponse {
+	return &overlapResponse{Overlaps: overlapPairs(cidrs, cidrs, netaddr.SelfOverlaps(cidrs))}
+}
+
+// compareSets returns every pair of CIDRs, one from a and one from b, that
+// overlap, and the relationship between the two sets.
+func compareSets(a, b namedSet) *overlapResponse {
+	relationship, intersection := netaddr.CompareSets(a.prefixes, b.prefixes)
+
+	return &overlapResponse{
+		Sets:         []string{a.name, b.name},
+		Relationship: relationship,
+		Intersection: intersection,
+		Overlaps:     overlapPairs(a.prefixes, b.prefixes, netaddr.Overlaps(a.prefixes, b.prefixes)),
 	}
+}
 
-	if !overlaps {
-		fmt.Fprintln(os.Stdout, "CIDRs do not overlap")
+func overlapPairs(a, b []netip.Prefix, overlaps []netaddr.Overlap) []overlapPair {
+	pairs := make([]overlapPair, len(overlaps))
+
+	for i, o := range overlaps {
+		pairs[i] = overlapPair{
+			A:            a[o.A],
+			B:            b[o.B],
+			Relationship: o.Relationship,
+			Intersection: netaddr.PrefixRange(o.Intersection),
+		}
 	}
 
-	return nil
+	return pairs
 }
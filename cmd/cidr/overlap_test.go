@@ -0,0 +1,181 @@
+package cidr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+)
+
+func TestReadSets(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "partners.txt")
+
+	if err := os.WriteFile(f, []byte("10.0.128.0/17\n10.1.2.0/24 # office\n10.0.128.0/17\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile returned an error when one wasn't expected: %+v", err)
+	}
+
+	named, err := readSets([]setArg{
+		{value: `ours=["10.0.0.0/16", "10.1.0.5/16", "10.1.0.0/16"]`},
+		{value: "partners=" + f, fromFile: true},
+	})
+	if err != nil {
+		t.Fatalf("readSets returned an error when one wasn't expected: %+v", err)
+	}
+
+	if len(named) != 2 || named[0].name != "ours" || named[1].name != "partners" {
+		t.Fatalf("unexpected sets %+v", named)
+	}
+
+	// CIDRs that cover the same block are only kept once
+	if len(named[0].prefixes) != 2 || named[0].prefixes[1].String() != "10.1.0.5/16" {
+		t.Errorf("expected the duplicate CIDR in ours to be removed, got %v", named[0].prefixes)
+	}
+
+	if len(named[1].prefixes) != 2 {
+		t.Errorf("expected the duplicate CIDR in partners to be removed, got %v", named[1].prefixes)
+	}
+
+	for _, tt := range []struct {
+		name string
+		sets []setArg
+		err  string
+	}{
+		{"NoName", jsonSets(`=["10.0.0.0/8"]`, `b=["10.0.0.0/8"]`), "must be in the form name=value"},
+		{"NoValue", jsonSets("a", `b=["10.0.0.0/8"]`), "must be in the form name=value"},
+		{"Duplicate", []setArg{{value: `a=["10.0.0.0/8"]`}, {value: "a=" + f, fromFile: true}}, "the set 'a' was provided more than once"},
+		{"One", jsonSets(`a=["10.0.0.0/8"]`), "exactly two sets are required, but 1 were provided"},
+		{"Three", jsonSets(`a=["10.0.0.0/8"]`, `b=["10.0.0.0/8"]`, `c=["10.0.0.0/8"]`), "exactly two sets are required, but 3 were provided"},
+		{"InvalidCIDR", jsonSets(`a=["10.0.0.0/33"]`, `b=["10.0.0.0/8"]`), "error reading set 'a'"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readSets(tt.sets)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing '%s', got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "ours.txt")
+
+	if err := os.WriteFile(f, []byte("10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile returned an error when one wasn't expected: %+v", err)
+	}
+
+	var sets []setArg
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Var(&setFlag{args: &sets}, "set", "")
+	cmd.Flags().Var(&setFlag{args: &sets, fromFile: true}, "set-file", "")
+
+	// The sets should keep the order they were provided in, even though
+	// --set-file comes before --set
+	if err := cmd.Flags().Parse([]string{"--set-file", "ours=" + f, "--set", `partners=["10.1.0.0/16"]`}); err != nil {
+		t.Fatalf("Parse returned an error when one wasn't expected: %+v", err)
+	}
+
+	named, err := readSets(sets)
+	if err != nil {
+		t.Fatalf("readSets returned an error when one wasn't expected: %+v", err)
+	}
+
+	if len(named) != 2 || named[0].name != "ours" || named[1].name != "partners" {
+		t.Fatalf("unexpected sets %+v", named)
+	}
+
+	var sb strings.Builder
+
+	if err := compareSets(named[0], named[1]).writeText(&sb); err != nil {
+		t.Fatalf("writeText returned an error when one wasn't expected: %+v", err)
+	}
+
+	if want := "ours contains partners\n"; !strings.HasSuffix(sb.String(), want) {
+		t.Errorf("expected the output to end with %q, got:\n%s", want, sb.String())
+	}
+}
+
+func TestCompareSetsText(t *testing.T) {
+	named, err := readSets(jsonSets(
+		`ours=["10.0.0.0/16", "10.1.0.0/16", "10.1.0.0/16"]`,
+		`partners=["10.0.128.0/17", "10.1.2.0/24", "10.2.0.0/16"]`,
+	))
+	if err != nil {
+		t.Fatalf("readSets returned an error when one wasn't expected: %+v", err)
+	}
+
+	var sb strings.Builder
+
+	if err := compareSets(named[0], named[1]).writeText(&sb); err != nil {
+		t.Fatalf("writeText returned an error when one wasn't expected: %+v", err)
+	}
+
+	want := `OURS         PARTNERS       RELATIONSHIP  INTERSECTION
+10.0.0.0/16  10.0.128.0/17  contains      10.0.128.0 - 10.0.255.255
+10.1.0.0/16  10.1.2.0/24    contains      10.1.2.0 - 10.1.2.255
+
+ours and partners partially overlap
+`
+
+	if sb.String() != want {
+		t.Errorf("expected:\n%s\nbut got:\n%s", want, sb.String())
+	}
+
+	for _, tt := range []struct {
+		a, b string
+		want string
+	}{
+		{`a=["10.0.0.0/24", "10.0.1.0/24"]`, `b=["10.0.0.0/23"]`, "a and b cover the same addresses\n"},
+		{`a=["10.0.0.0/8"]`, `b=["10.1.0.0/16", "10.2.0.0/16"]`, "a contains b\n"},
+		{`a=["10.1.0.0/16"]`, `b=["10.0.0.0/8"]`, "a is contained by b\n"},
+		{`a=["10.0.0.0/8"]`, `b=["192.168.0.0/16"]`, "a and b do not overlap\n"},
+	} {
+		named, err := readSets(jsonSets(tt.a, tt.b))
+		if err != nil {
+			t.Fatalf("readSets returned an error when one wasn't expected: %+v", err)
+		}
+
+		var sb strings.Builder
+
+		if err := compareSets(named[0], named[1]).writeText(&sb); err != nil {
+			t.Fatalf("writeText returned an error when one wasn't expected: %+v", err)
+		}
+
+		if got := sb.String(); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("expected the output to end with %q, got:\n%s", tt.want, got)
+		}
+	}
+}
+
+func TestDoCIDRsOverlap(t *testing.T) {
+	// CIDRs with host bits set are compared as the block they are in, and
+	// written as given
+	prefixes, err := input.ParseHostPrefixes([]string{"87.243.24.122/24", "87.243.24.0/24", "87.243.25.0/24"})
+	if err != nil {
+		t.Fatalf("ParseHostPrefixes returned an error when one wasn't expected: %+v", err)
+	}
+
+	var sb strings.Builder
+
+	if err := doCIDRsOverlap(prefixes).writeText(&sb); err != nil {
+		t.Fatalf("writeText returned an error when one wasn't expected: %+v", err)
+	}
+
+	if want := "CIDRs (87.243.24.122/24) and (87.243.24.0/24) overlap\n"; sb.String() != want {
+		t.Errorf("expected %q but got %q", want, sb.String())
+	}
+}
+
+// jsonSets returns values as --set flags.
+func jsonSets(values ...string) []setArg {
+	sets := make([]setArg, len(values))
+	for i, v := range values {
+		sets[i] = setArg{value: v}
+	}
+
+	return sets
+}
@@ -1,131 +1,266 @@
-// Note: All credit for code in this command goes to https://github.com/vladimirvivien/go-networking/blob/master/ip/cidr/cidr.go
+// Note: This command was originally based on https://github.com/vladimirvivien/go-networking/blob/master/ip/cidr/cidr.go
 
 package cidr
 
 import (
+	"errors"
 	"fmt"
-	"math"
-	"net"
+	"io"
+	"math/big"
+	"net/netip"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
 )
 
 func newParseCommand() *cobra.Command {
-	var cidr string
+	var (
+		cidrs []string
+		file  string
+	)
+
+	format := output.Text
 
 	cmd := &cobra.Command{
-		Use:   "parse",
+		Use:   "parse [cidr]...",
 		Short: "parses the cidr and outputs relevant information",
+		Long:  "parses one or more CIDRs and outputs relevant information, as a table if more than one CIDR is provided",
 		Example: `
 $ genc cidr parse --cidr "192.168.1.0/24"
 
 CIDR: 192.168.1.0/24
 ------------------------
-Network:        192.168.1.0
-IP Range:       192.168.1.0 - 192.168.1.255
-Total Hosts:    256
-Netmask:        255.255.255.0
-Wildcard Mask:  0.0.0.255`,
+Family:                 IPv4
+Prefix Length:          24
+Network:                192.168.1.0
+Broadcast:              192.168.1.255
+IP Range:               192.168.1.0 - 192.168.1.255
+Total Hosts:            256
+Usable Hosts:           254
+Usable Range:           192.168.1.1 - 192.168.1.254
+Netmask:                255.255.255.0
+Wildcard Mask:          0.0.0.255
+Reverse Zone:           1.168.192.in-addr.arpa.
+
+$ genc cidr parse --cidr "2001:db8:abcd::/48"
+
+CIDR: 2001:db8:abcd::/48
+------------------------
+Family:                 IPv6
+Prefix Length:          48
+Network:                2001:db8:abcd::
+IP Range:               2001:db8:abcd:: - 2001:db8:abcd:ffff:ffff:ffff:ffff:ffff
+Total Hosts:            1208925819614629174706176
+Usable Hosts:           1208925819614629174706176
+Usable Range:           2001:db8:abcd:: - 2001:db8:abcd:ffff:ffff:ffff:ffff:ffff
+Netmask:                ffff:ffff:ffff::
+Wildcard Mask:          ::ffff:ffff:ffff:ffff:ffff
+Expanded Network:       2001:0db8:abcd:0000:0000:0000:0000:0000
+Reverse Zone:           d.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa.
+
+$ genc cidr parse 10.0.0.0/16 10.1.0.0/24 2001:db8::/64
+CIDR           FAMILY  FIRST       LAST                           TOTAL HOSTS           USABLE HOSTS
+10.0.0.0/16    IPv4    10.0.0.0    10.0.255.255                   65536                 65534
+10.1.0.0/24    IPv4    10.1.0.0    10.1.0.255                     256                   254
+2001:db8::/64  IPv6    2001:db8::  2001:db8::ffff:ffff:ffff:ffff  18446744073709551616  18446744073709551616
+
+# Parse an address plan, one CIDR per line, as JSON
+$ genc cidr parse --file plan.txt --output json`,
 		Run: func(cmd *cobra.Command, args []string) {
-			pr, err := parseCIDR(cidr)
+			list, err := input.Read(append(cidrs, args...), file)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing CIDR: %w", err))
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
 				os.Exit(1)
 			}
 
-			fmt.Fprintln(os.Stdin)
-			fmt.Fprintf(os.Stdin, "CIDR: %s\n", pr.cidr)
-			fmt.Fprintln(os.Stdin, "------------------------")
-			fmt.Fprintf(os.Stdin, "Network:        %s\n", pr.network)
-			fmt.Fprintf(os.Stdin, "IP Range:       %s\n", pr.ipRange)
-			fmt.Fprintf(os.Stdin, "Total Hosts:    %s\n", pr.totalHosts)
-			fmt.Fprintf(os.Stdin, "Netmask:        %s\n", pr.netmask)
-			fmt.Fprintf(os.Stdin, "Wildcard Mask:  %s\n", pr.wildcardMask)
-			fmt.Fprintln(os.Stdin)
-		},
-	}
+			if len(list) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidr or --file"))
+				os.Exit(1)
+			}
+
+			prs := make([]*parseResponse, len(list))
+
+			for i, c := range list {
+				if prs[i], err = parseCIDR(c); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing CIDR '%s': %w", c, err))
+					os.Exit(1)
+				}
+			}
 
-	cmd.Flags().StringVar(&cidr, "cidr", "", "the CIDR to parse")
+			switch {
+			case format == output.JSON:
+				err = output.WriteJSON(os.Stdout, prs)
+			case len(prs) == 1:
+				err = prs[0].writeText(os.Stdout)
+			default:
+				err = writeParseTable(os.Stdout, prs)
+			}
 
-	if err := cmd.MarkFlagRequired("cidr"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cidr' as required: %w", err))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
 	}
 
+	cmd.Flags().StringArrayVar(&cidrs, "cidr", nil, "the CIDR to parse, can be repeated")
+	cmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to parse, one per line, or - to read from stdin")
+	cmd.Flags().Var(&format, "output", "the output format")
+
 	return cmd
 }
 
 type parseResponse struct {
-	cidr         string
-	network      string
-	ipRange      string
-	totalHosts   string
-	netmask      string
-	wildcardMask string
+	CIDR         string `json:"cidr"`
+	Family       string `json:"family"`
+	PrefixLength int    `json:"prefixLength"`
+	Network      string `json:"network"`
+	Broadcast    string `json:"broadcast,omitempty"`
+	First        string `json:"first"`
+	Last         string `json:"last"`
+	TotalHosts   string `json:"totalHosts"`
+	UsableHosts  string `json:"usableHosts"`
+	FirstUsable  string `json:"firstUsable,omitempty"`
+	LastUsable   string `json:"lastUsable,omitempty"`
+	Netmask      string `json:"netmask"`
+	WildcardMask string `json:"wildcardMask"`
+	Expanded     string `json:"expanded,omitempty"`
+	ReverseZone  string `json:"reverseZone"`
+	// ReverseZoneExact is false if the prefix isn't on an octet (IPv4) or
+	// nibble (IPv6) boundary, so ReverseZone is the enclosing zone
+	ReverseZoneExact bool `json:"reverseZoneExact"`
+}
+
+func (pr *parseResponse) writeText(w io.Writer) error {
+	var sb strings.Builder
+
+	field := func(label string, value interface{}) {
+		fmt.Fprintf(&sb, "%-24s%v\n", label+":", value)
+	}
+
+	fmt.Fprintln(&sb)
+	fmt.Fprintf(&sb, "CIDR: %s\n", pr.CIDR)
+	fmt.Fprintln(&sb, "------------------------")
+	field("Family", pr.Family)
+	field("Prefix Length", pr.PrefixLength)
+	field("Network", pr.Network)
+
+	if pr.Broadcast != "" {
+		field("Broadcast", pr.Broadcast)
+	}
+
+	field("IP Range", fmt.Sprintf("%s - %s", pr.First, pr.Last))
+	field("Total Hosts", pr.TotalHosts)
+	field("Usable Hosts", pr.UsableHosts)
+
+	if pr.FirstUsable != "" {
+		field("Usable Range", fmt.Sprintf("%s - %s", pr.FirstUsable, pr.LastUsable))
+	} else {
+		field("Usable Range", "none")
+	}
+
+	field("Netmask", pr.Netmask)
+	field("Wildcard Mask", pr.WildcardMask)
+
+	if pr.Expanded != "" {
+		field("Expanded Network", pr.Expanded)
+	}
+
+	if pr.ReverseZoneExact {
+		field("Reverse Zone", pr.ReverseZone)
+	} else {
+		field("Reverse Zone", pr.ReverseZone+" (the prefix isn't on a delegation boundary)")
+	}
+
+	fmt.Fprintln(&sb)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func writeParseTable(w io.Writer, prs []*parseResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CIDR\tFAMILY\tFIRST\tLAST\tTOTAL HOSTS\tUSABLE HOSTS")
+
+	for _, pr := range prs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", pr.CIDR, pr.Family, pr.First, pr.Last, pr.TotalHosts, pr.UsableHosts)
+	}
+
+	return tw.Flush()
 }
 
 func parseCIDR(cidr string) (*parseResponse, error) {
-	ip, ipnet, err := net.ParseCIDR(cidr)
+	p, err := netaddr.ParsePrefix(cidr)
 	if err != nil {
 		return nil, err
 	}
 
-	if ipnet == nil || cidr != ipnet.String() {
-		return nil, fmt.Errorf("invalid CIDR block - did you mean '%s'?", ipnet)
-	}
-
-	// Given IPv4 block 192.168.100.14/24
-	// The followings uses IPNet to get:
+	// Given IPv4 block 192.168.100.0/24, the following are calculated:
 	// - The routing address for the subnet (i.e. 192.168.100.0)
 	// - one-bits of the network mask (24 out of 32 total)
 	// - The subnetmask (i.e. 255.255.255.0)
 	// - Total hosts in the network (2 ^(host identifer bits) or 2^8)
 	// - Wildcard the inverse of subnet mask (i.e. 0.0.0.255)
 	// - The maximum address of the subnet (i.e. 192.168.100.255)
-	ones, totalBits := ipnet.Mask.Size()
-	size := totalBits - ones                 // usable bits
-	totalHosts := math.Pow(2, float64(size)) // 2^size
-	wildcardIP := wildcard(net.IP(ipnet.Mask))
-	last := lastIP(ip, net.IPMask(wildcardIP))
-
-	return &parseResponse{
-		cidr:         cidr,
-		network:      ipnet.IP.String(),
-		ipRange:      fmt.Sprintf("%s - %s", ipnet.IP, last),
-		totalHosts:   fmt.Sprintf("%0.0f", totalHosts),
-		netmask:      net.IP(ipnet.Mask).String(),
-		wildcardMask: wildcardIP.String(),
-	}, nil
-}
+	//
+	// Host counts use big integers, as an IPv6 prefix can hold 2^128 addresses
+	network := p.Addr()
+	last := netaddr.Last(p)
+	total := netaddr.Size(p)
+	first, final, usable := usableHosts(p)
 
-// wildcard returns the opposite of the
-// the netmask for the network.
-func wildcard(mask net.IP) net.IP {
-	var ipVal net.IP
+	pr := &parseResponse{
+		CIDR:         p.String(),
+		Family:       netaddr.Family(network),
+		PrefixLength: p.Bits(),
+		Network:      network.String(),
+		First:        network.String(),
+		Last:         last.String(),
+		TotalHosts:   total.String(),
+		UsableHosts:  usable.String(),
+		Netmask:      netaddr.Mask(p).String(),
+		WildcardMask: netaddr.Wildcard(p).String(),
+	}
 
-	for _, octet := range mask {
-		ipVal = append(ipVal, ^octet)
+	if usable.Sign() > 0 {
+		pr.FirstUsable, pr.LastUsable = first.String(), final.String()
 	}
 
-	return ipVal
-}
+	if network.Is4() && p.Bits() < 31 {
+		pr.Broadcast = last.String()
+	}
 
-// lastIP calculates the highest addressable IP for given
-// for a given subnet. It Loops through each octet of the
-// subnet's IP address and applies bitwise OR operation
-// to each corresponding octet from the mask value.
-func lastIP(ip net.IP, mask net.IPMask) net.IP {
-	ipIn := ip.To4() // is it an IPv4
-	if ipIn == nil {
-		ipIn = ip.To16() // is it IPv6
-		if ipIn == nil {
-			return nil
-		}
+	if network.Is6() {
+		pr.Expanded = network.StringExpanded()
 	}
-	var ipVal net.IP
-	// apply network mask to each octet
-	for i, octet := range ipIn {
-		ipVal = append(ipVal, octet|mask[i])
+
+	pr.ReverseZone, pr.ReverseZoneExact = netaddr.ReverseZone(p)
+
+	return pr, nil
+}
+
+// usableHosts returns the first and last usable host addresses in p, and
+// the number of usable hosts.
+//
+// The network and broadcast addresses of IPv4 prefixes aren't usable, except
+// in /31 point-to-point links (RFC 3021) and /32 host routes. IPv6 has no
+// broadcast address, so every address is usable.
+func usableHosts(p netip.Prefix) (netip.Addr, netip.Addr, *big.Int) {
+	first, last := p.Addr(), netaddr.Last(p)
+	usable := netaddr.Size(p)
+
+	if first.Is4() && p.Bits() < 31 {
+		first, last = first.Next(), last.Prev()
+		usable.Sub(usable, big.NewInt(2))
 	}
-	return ipVal
+
+	return first, last, usable
 }
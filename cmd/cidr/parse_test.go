@@ -0,0 +1,38 @@
+package cidr
+
+import "testing"
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		cidr        string
+		totalHosts  string
+		usableHosts string
+		usableRange string
+	}{
+		{"192.168.1.0/24", "256", "254", "192.168.1.1 - 192.168.1.254"},
+		{"10.0.0.0/31", "2", "2", "10.0.0.0 - 10.0.0.1"},
+		{"10.0.0.1/32", "1", "1", "10.0.0.1 - 10.0.0.1"},
+		{"2001:db8:abcd::/48", "1208925819614629174706176", "1208925819614629174706176", "2001:db8:abcd:: - 2001:db8:abcd:ffff:ffff:ffff:ffff:ffff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			pr, err := parseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("parseCIDR returned an error when one wasn't expected: %+v", err)
+			}
+
+			usableRange := pr.FirstUsable + " - " + pr.LastUsable
+
+			if pr.TotalHosts != tt.totalHosts || pr.UsableHosts != tt.usableHosts || usableRange != tt.usableRange {
+				t.Errorf("expected %s/%s (%s) but got %s/%s (%s)", tt.totalHosts, tt.usableHosts, tt.usableRange, pr.TotalHosts, pr.UsableHosts, usableRange)
+			}
+		})
+	}
+
+	t.Run("HostBits", func(t *testing.T) {
+		if _, err := parseCIDR("192.168.1.5/24"); err == nil {
+			t.Errorf("parseCIDR was expected to return an error but didn't")
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newFromRangeCommand() *cobra.Command {
+	var (
+		start string
+		end   string
+		file  string
+	)
+
+	format := output.Text
+
+	fromRangeCmd := &cobra.Command{
+		Use:   "from-range [start-end]...",
+		Short: "convert IP address ranges to the minimal list of CIDRs that exactly cover them",
+		Long: `convert IP address ranges to the minimal list of CIDRs that exactly cover them
+
+Ranges can be provided with --start and --end, as start-end arguments, or one per line in a file or on stdin.
+Overlapping and adjacent ranges are combined before being converted.`,
+		Example: `
+    $ genc cidr from-range --start 10.0.0.5 --end 10.0.0.40
+    10.0.0.5/32
+    10.0.0.6/31
+    10.0.0.8/29
+    10.0.0.16/28
+    10.0.0.32/29
+    10.0.0.40/32
+
+    $ genc cidr from-range 2001:db8::-2001:db8::ffff 192.168.0.0-192.168.1.255
+    192.168.0.0/23
+    2001:db8::/112
+
+    $ genc cidr from-range --file vendor-ranges.txt --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if start != "" {
+				args = append(args, start+"-"+end)
+			}
+
+			list, err := input.Read(args, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading ranges: %w", err))
+				os.Exit(1)
+			}
+
+			if len(list) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one range is required, as an argument or with --start and --end or --file"))
+				os.Exit(1)
+			}
+
+			fr, err := rangesToCIDRs(list)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error converting ranges: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, fr)
+			} else {
+				err = writePrefixes(os.Stdout, fr.CIDRs)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	fromRangeCmd.Flags().StringVar(&start, "start", "", "the first address in the range")
+	fromRangeCmd.Flags().StringVar(&end, "end", "", "the last address in the range")
+	fromRangeCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing ranges, one per line, or - to read from stdin")
+	fromRangeCmd.Flags().Var(&format, "output", "the output format")
+
+	fromRangeCmd.MarkFlagsRequiredTogether("start", "end")
+
+	return fromRangeCmd
+}
+
+type fromRangeResponse struct {
+	Ranges []netaddr.Range `json:"ranges"`
+	CIDRs  []netip.Prefix  `json:"cidrs"`
+}
+
+// rangesToCIDRs returns the minimal list of CIDRs that exactly cover the
+// ranges in list.
+func rangesToCIDRs(list []string) (*fromRangeResponse, error) {
+	ranges := make([]netaddr.Range, len(list))
+
+	for i, s := range list {
+		r, err := netaddr.ParseRange(s)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing range '%s': %w", s, err)
+		}
+
+		ranges[i] = r
+	}
+
+	fr := &fromRangeResponse{Ranges: netaddr.MergeRanges(ranges)}
+
+	for _, r := range fr.Ranges {
+		fr.CIDRs = append(fr.CIDRs, r.Prefixes()...)
+	}
+
+	return fr, nil
+}
+
+func newToRangeCommand() *cobra.Command {
+	var (
+		cidrs string
+		file  string
+		merge bool
+	)
+
+	format := output.Text
+
+	toRangeCmd := &cobra.Command{
+		Use:   "to-range [cidr]...",
+		Short: "convert CIDRs to IP address ranges",
+		Long: `convert CIDRs to IP address ranges
+
+CIDRs can be provided as arguments, as a JSON list with --cidrs, or one per line in a file or on stdin.`,
+		Example: `
+    $ genc cidr to-range 10.0.0.0/24 10.0.1.0/24 2001:db8::/112
+    10.0.0.0-10.0.0.255
+    10.0.1.0-10.0.1.255
+    2001:db8::-2001:db8::ffff
+
+    # Combine overlapping and adjacent CIDRs into a single range
+    $ genc cidr to-range 10.0.0.0/24 10.0.1.0/24 --merge
+    10.0.0.0-10.0.1.255`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prefixes, err := input.Prefixes(args, cidrs, file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
+				os.Exit(1)
+			}
+
+			if len(prefixes) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one CIDR is required, as an argument or with --cidrs or --file"))
+				os.Exit(1)
+			}
+
+			trs := cidrsToRanges(prefixes, merge)
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, trs)
+			} else {
+				for _, tr := range trs {
+					if _, err = fmt.Fprintf(os.Stdout, "%s-%s\n", tr.From, tr.To); err != nil {
+						break
+					}
+				}
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	toRangeCmd.Flags().StringVar(&cidrs, "cidrs", "", "the list of CIDRs to convert, as JSON")
+	toRangeCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing CIDRs to convert, one per line, or - to read from stdin")
+	toRangeCmd.Flags().BoolVar(&merge, "merge", false, "combine overlapping and adjacent CIDRs into a single range")
+	toRangeCmd.Flags().Var(&format, "output", "the output format")
+
+	return toRangeCmd
+}
+
+type toRangeResponse struct {
+	CIDRs []netip.Prefix `json:"cidrs"`
+	From  netip.Addr     `json:"from"`
+	To    netip.Addr     `json:"to"`
+	Size  string         `json:"size"`
+}
+
+// cidrsToRanges returns the range of addresses in each of prefixes, or if
+// merge is set, the ranges covered by prefixes.
+func cidrsToRanges(prefixes []netip.Prefix, merge bool) []toRangeResponse {
+	var trs []toRangeResponse
+
+	if !merge {
+		for _, p := range prefixes {
+			r := netaddr.PrefixRange(p)
+			trs = append(trs, toRangeResponse{CIDRs: []netip.Prefix{p}, From: r.From, To: r.To, Size: r.Size().String()})
+		}
+
+		return trs
+	}
+
+	for _, r := range netaddr.MergeRanges(netaddr.PrefixRanges(prefixes)) {
+		trs = append(trs, toRangeResponse{CIDRs: r.Prefixes(), From: r.From, To: r.To, Size: r.Size().String()})
+	}
+
+	return trs
+}
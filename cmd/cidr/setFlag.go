@@ -0,0 +1,35 @@
+// setFlag implements a custom type to be used with Cobra.
+//
+// It's used by both --set and --set-file, which append to the same list, so
+// that the sets keep the order they were provided in on the command line.
+
+package cidr
+
+type setArg struct {
+	// value is in the form name=value, where value is a JSON list, or the
+	// path to a file if fromFile is true
+	value    string
+	fromFile bool
+}
+
+type setFlag struct {
+	args     *[]setArg
+	fromFile bool
+}
+
+func (f *setFlag) String() string {
+	return ""
+}
+
+func (f *setFlag) Set(v string) error {
+	*f.args = append(*f.args, setArg{value: v, fromFile: f.fromFile})
+	return nil
+}
+
+func (f *setFlag) Type() string {
+	if f.fromFile {
+		return "name=path"
+	}
+
+	return "name=list"
+}
@@ -0,0 +1,114 @@
+package cidr
+
+import (
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+// maxSplitBits limits the number of subnets a CIDR can be split into to
+// 2^16, as splitting an IPv6 prefix could otherwise produce billions.
+const maxSplitBits = 16
+
+func newSplitCommand() *cobra.Command {
+	var (
+		cidr   string
+		prefix int
+		count  int
+	)
+
+	format := output.Text
+
+	splitCmd := &cobra.Command{
+		Use:   "split",
+		Short: "split a CIDR into equal sized subnets",
+		Example: `
+    $ genc cidr split --cidr 10.0.0.0/16 --prefix 18
+    CIDR           FAMILY  FIRST        LAST           TOTAL HOSTS  USABLE HOSTS
+    10.0.0.0/18    IPv4    10.0.0.0     10.0.63.255    16384        16382
+    10.0.64.0/18   IPv4    10.0.64.0    10.0.127.255   16384        16382
+    10.0.128.0/18  IPv4    10.0.128.0   10.0.191.255   16384        16382
+    10.0.192.0/18  IPv4    10.0.192.0   10.0.255.255   16384        16382
+
+    # Split into at least 6 subnets, which rounds up to 8
+    $ genc cidr split --cidr 2001:db8::/48 --count 6 --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prs, err := splitCIDR(cidr, prefix, count)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error splitting CIDR: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, prs)
+			} else {
+				err = writeParseTable(os.Stdout, prs)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	splitCmd.Flags().StringVar(&cidr, "cidr", "", "the CIDR to split")
+	splitCmd.Flags().IntVar(&prefix, "prefix", 0, "the prefix length of the subnets")
+	splitCmd.Flags().IntVar(&count, "count", 0, "the minimum number of subnets, rounded up to a power of two")
+	splitCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := splitCmd.MarkFlagRequired("cidr"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cidr' as required: %w", err))
+	}
+
+	splitCmd.MarkFlagsOneRequired("prefix", "count")
+	splitCmd.MarkFlagsMutuallyExclusive("prefix", "count")
+
+	return splitCmd
+}
+
+// splitCIDR splits cidr into subnets with the prefix length prefix, or if
+// count is set, into the smallest power of two subnets that is at least
+// count.
+func splitCIDR(cidr string, prefix, count int) ([]*parseResponse, error) {
+	p, err := netaddr.ParsePrefix(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		prefix = p.Bits() + bits.Len(uint(count-1))
+	}
+
+	if n := prefix - p.Bits(); n > maxSplitBits {
+		return nil, fmt.Errorf("splitting %s into /%d subnets would produce more than %d subnets", p, prefix, 1<<maxSplitBits)
+	}
+
+	var (
+		prs      []*parseResponse
+		parseErr error
+	)
+
+	err = netaddr.Split(p, prefix, func(sub netip.Prefix) bool {
+		var pr *parseResponse
+
+		if pr, parseErr = parseCIDR(sub.String()); parseErr != nil {
+			return false
+		}
+
+		prs = append(prs, pr)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prs, parseErr
+}
@@ -0,0 +1,124 @@
+package csr
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newCreateCommand() *cobra.Command {
+	var (
+		privateKey     string
+		passphraseFile string
+		subject        string
+		sans           []string
+		keyUsage       []string
+		extKeyUsage    []string
+		out            string
+	)
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a certificate signing request",
+		Example: `
+    # Create a CSR for a server certificate
+    $ genc csr create --private-key rsa.key --subject "/C=GB/O=Wibble Wobble, Inc./CN=example.com" --san example.com --san www.example.com --san 10.0.0.1 --key-usage digitalSignature,keyEncipherment --ext-key-usage serverAuth --out domain.csr
+
+    # Subject alternative names can be prefixed with their type (DNS:, IP:, EMAIL: or URI:)
+    $ genc csr create --private-key rsa.key --subject "CN=wibble" --san EMAIL:wibble@example.com --san URI:spiffe://example.com/wibble`,
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := keys.LoadPrivateKey(privateKey, keys.Passphrase(passphraseFile))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error loading private key: %w", err))
+				os.Exit(1)
+			}
+
+			der, err := createCSR(key, subject, sans, keyUsage, extKeyUsage)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error creating csr: %w", err))
+				os.Exit(1)
+			}
+
+			if err := output.WriteFile(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing csr: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	createCmd.Flags().StringVar(&privateKey, "private-key", "", "the location of the private key on disk")
+	createCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
+	createCmd.Flags().StringVar(&subject, "subject", "", "the subject, e.g. /C=GB/O=Wibble/CN=example.com or CN=example.com,O=Wibble")
+	createCmd.Flags().StringSliceVar(&sans, "san", nil, "subject alternative names")
+	createCmd.Flags().StringSliceVar(&keyUsage, "key-usage", nil, "requested key usages, e.g. digitalSignature,keyEncipherment")
+	createCmd.Flags().StringSliceVar(&extKeyUsage, "ext-key-usage", nil, "requested extended key usages, e.g. serverAuth,clientAuth")
+	createCmd.Flags().StringVar(&out, "out", "", "the location to write the csr to, defaults to stdout")
+
+	if err := createCmd.MarkFlagRequired("private-key"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'private-key' as required: %w", err))
+	}
+	if err := createCmd.MarkFlagRequired("subject"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'subject' as required: %w", err))
+	}
+
+	return createCmd
+}
+
+func createCSR(key crypto.PrivateKey, subject string, sans, keyUsage, extKeyUsage []string) ([]byte, error) {
+	name, err := certs.ParseSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := certs.ParseSANs(sans)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.CertificateRequest{
+		Subject:        name,
+		DNSNames:       s.DNSNames,
+		IPAddresses:    s.IPAddresses,
+		EmailAddresses: s.EmailAddresses,
+		URIs:           s.URIs,
+	}
+
+	if len(keyUsage) > 0 {
+		ku, err := certs.ParseKeyUsage(keyUsage)
+		if err != nil {
+			return nil, err
+		}
+
+		ext, err := certs.KeyUsageExtension(ku)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+
+	if len(extKeyUsage) > 0 {
+		eku, err := certs.ParseExtKeyUsage(extKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+
+		ext, err := certs.ExtKeyUsageExtension(eku)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+
+	return x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+}
@@ -0,0 +1,14 @@
+package csr
+
+import "github.com/spf13/cobra"
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "csr",
+		Short: "certificate signing request related commands",
+	}
+
+	cmd.AddCommand(newCreateCommand())
+
+	return cmd
+}
@@ -0,0 +1,38 @@
+// addrFormat implements a custom type to be used with Cobra.
+//
+// It ensures that the address format is one of canonical, int, hex, binary,
+// mapped, expanded, or ptr.
+
+package ip
+
+import "errors"
+
+type addrFormat string
+
+const (
+	addrFormatCanonical addrFormat = "canonical"
+	addrFormatInt       addrFormat = "int"
+	addrFormatHex       addrFormat = "hex"
+	addrFormatBinary    addrFormat = "binary"
+	addrFormatMapped    addrFormat = "mapped"
+	addrFormatExpanded  addrFormat = "expanded"
+	addrFormatPTR       addrFormat = "ptr"
+)
+
+func (f *addrFormat) String() string {
+	return string(*f)
+}
+
+func (f *addrFormat) Set(v string) error {
+	switch addrFormat(v) {
+	case addrFormatCanonical, addrFormatInt, addrFormatHex, addrFormatBinary, addrFormatMapped, addrFormatExpanded, addrFormatPTR:
+		*f = addrFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of canonical, int, hex, binary, mapped, expanded, or ptr`)
+	}
+}
+
+func (f *addrFormat) Type() string {
+	return "[canonical,int,hex,binary,mapped,expanded,ptr]"
+}
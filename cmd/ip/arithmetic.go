@@ -0,0 +1,186 @@
+package ip
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newAddCommand() *cobra.Command {
+	var (
+		ip     string
+		offset string
+	)
+
+	format := output.Text
+
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "add an offset to an IP address",
+		Example: `
+    $ genc ip add --ip 10.0.0.255 --offset 1
+    10.0.1.0
+
+    $ genc ip add --ip 2001:db8::1 --offset -2
+    2001:db7:ffff:ffff:ffff:ffff:ffff:ffff
+
+    $ genc ip add --ip 2001:db8:: --offset 0x10000
+    2001:db8::1:0`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ar, err := addIP(ip, offset)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error adding offset to IP: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, ar)
+			} else {
+				_, err = fmt.Fprintln(os.Stdout, ar.Result)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	addCmd.Flags().StringVar(&ip, "ip", "", "the ip address")
+	addCmd.Flags().StringVar(&offset, "offset", "", "the number of addresses to add, which can be negative, or hex with a 0x prefix")
+	addCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := addCmd.MarkFlagRequired("ip"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'ip' as required: %w", err))
+	}
+	if err := addCmd.MarkFlagRequired("offset"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'offset' as required: %w", err))
+	}
+
+	return addCmd
+}
+
+type addResponse struct {
+	IP     netip.Addr `json:"ip"`
+	Offset string     `json:"offset"`
+	Result netip.Addr `json:"result"`
+}
+
+func addIP(ip, offset string) (*addResponse, error) {
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	// Base 0 accepts decimal, and hex with a 0x prefix
+	n, ok := new(big.Int).SetString(offset, 0)
+	if !ok {
+		return nil, fmt.Errorf("unable to parse offset '%s'", offset)
+	}
+
+	result, err := netaddr.Add(a, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &addResponse{IP: a, Offset: n.String(), Result: result}, nil
+}
+
+func newDiffCommand() *cobra.Command {
+	var (
+		from string
+		to   string
+	)
+
+	format := output.Text
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "output the number of addresses between two IP addresses",
+		Long: `output the number of addresses between two IP addresses, which is negative if --to is before --from
+
+--output json also includes the number of addresses in the range, including both ends.`,
+		Example: `
+    $ genc ip diff --from 10.0.0.0 --to 10.0.1.0
+    256
+
+    $ genc ip diff --from 2001:db8::ffff --to 2001:db8:: --output json
+    {
+      "from": "2001:db8::ffff",
+      "to": "2001:db8::",
+      "difference": "-65535",
+      "addresses": "65536"
+    }`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dr, err := diffIPs(from, to)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error comparing IPs: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, dr)
+			} else {
+				_, err = fmt.Fprintln(os.Stdout, dr.Difference)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	diffCmd.Flags().StringVar(&from, "from", "", "the ip address to count from")
+	diffCmd.Flags().StringVar(&to, "to", "", "the ip address to count to")
+	diffCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := diffCmd.MarkFlagRequired("from"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'from' as required: %w", err))
+	}
+	if err := diffCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'to' as required: %w", err))
+	}
+
+	return diffCmd
+}
+
+type diffResponse struct {
+	From       netip.Addr `json:"from"`
+	To         netip.Addr `json:"to"`
+	Difference string     `json:"difference"`
+	// Addresses is the size of the range between From and To, inclusive
+	Addresses string `json:"addresses"`
+}
+
+func diffIPs(from, to string) (*diffResponse, error) {
+	a, err := netip.ParseAddr(from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := netip.ParseAddr(to)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := netaddr.Diff(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	size := new(big.Int).Abs(d)
+
+	return &diffResponse{
+		From:       a,
+		To:         b,
+		Difference: d.String(),
+		Addresses:  size.Add(size, big.NewInt(1)).String(),
+	}, nil
+}
@@ -0,0 +1,180 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newConvertCommand() *cobra.Command {
+	var (
+		ip   string
+		ipv6 bool
+		to   addrFormat
+	)
+
+	format := output.Text
+
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "convert an IP address between formats",
+		Long: `convert an IP address between formats
+
+The address can be provided as text (192.0.2.1 or 2001:db8::1), an integer, hex (0x...), binary (0b..., or as written by
+this command), or a PTR name (1.2.0.192.in-addr.arpa). Integers, hex and binary that fit in 32 bits are treated as IPv4
+addresses, unless --ipv6 is set.`,
+		Example: `
+    $ genc ip convert --ip 3221225985
+
+    IP: 3221225985
+    ------------------------
+    Family:                 IPv4
+    Canonical:              192.0.2.1
+    Integer:                3221225985
+    Hex:                    0xc0000201
+    Binary:                 11000000.00000000.00000010.00000001
+    IPv4-mapped IPv6:       ::ffff:192.0.2.1
+    PTR:                    1.2.0.192.in-addr.arpa.
+
+    $ genc ip convert --ip 2001:db8::1 --to ptr
+    1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.
+
+    $ genc ip convert --ip 0x20010db8000000000000000000000001 --to canonical
+    2001:db8::1`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cr, err := convertIP(ip, ipv6)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing IP '%s': %w", ip, err))
+				os.Exit(1)
+			}
+
+			switch {
+			case to != "":
+				var v string
+
+				if v, err = cr.format(to); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error converting IP '%s': %w", ip, err))
+					os.Exit(1)
+				}
+
+				_, err = fmt.Fprintln(os.Stdout, v)
+			case format == output.JSON:
+				err = output.WriteJSON(os.Stdout, cr)
+			default:
+				err = cr.writeText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	convertCmd.Flags().StringVar(&ip, "ip", "", "the ip address, in any of the supported formats")
+	convertCmd.Flags().BoolVar(&ipv6, "ipv6", false, "treat integers, hex and binary as IPv6 addresses")
+	convertCmd.Flags().Var(&to, "to", "only output the address in this format")
+	convertCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := convertCmd.MarkFlagRequired("ip"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'ip' as required: %w", err))
+	}
+
+	convertCmd.MarkFlagsMutuallyExclusive("to", "output")
+
+	return convertCmd
+}
+
+type convertResponse struct {
+	addrDetails
+	// Mapped is the IPv4-mapped IPv6 form of an IPv4 address, and Unmapped
+	// the IPv4 form of an IPv4-mapped IPv6 address
+	Mapped   string `json:"mapped,omitempty"`
+	Unmapped string `json:"unmapped,omitempty"`
+	PTR      string `json:"ptr"`
+}
+
+func (cr *convertResponse) writeText(w io.Writer) error {
+	var sb strings.Builder
+
+	cr.writeFields(&sb)
+
+	if cr.Mapped != "" {
+		field(&sb, "IPv4-mapped IPv6", cr.Mapped)
+	}
+
+	if cr.Unmapped != "" {
+		field(&sb, "IPv4", cr.Unmapped)
+	}
+
+	field(&sb, "PTR", cr.PTR)
+	fmt.Fprintln(&sb)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// format returns the address in the format f.
+func (cr *convertResponse) format(f addrFormat) (string, error) {
+	switch f {
+	case addrFormatInt:
+		return cr.Integer, nil
+	case addrFormatHex:
+		return cr.Hex, nil
+	case addrFormatBinary:
+		return cr.Binary, nil
+	case addrFormatMapped:
+		switch {
+		case cr.Mapped != "":
+			return cr.Mapped, nil
+		case cr.Unmapped != "":
+			// The address is already IPv4-mapped
+			return cr.Canonical, nil
+		default:
+			return "", errors.New("only IPv4 addresses can be mapped to IPv6")
+		}
+	case addrFormatExpanded:
+		if cr.Expanded == "" {
+			return "", errors.New("only IPv6 addresses can be expanded")
+		}
+
+		return cr.Expanded, nil
+	case addrFormatPTR:
+		return cr.PTR, nil
+	default:
+		return cr.Canonical, nil
+	}
+}
+
+func convertIP(ip string, ipv6 bool) (*convertResponse, error) {
+	a, err := netaddr.Parse(ip, ipv6)
+	if err != nil {
+		return nil, err
+	}
+
+	a = a.WithZone("")
+
+	cr := &convertResponse{
+		addrDetails: describeAddr(ip, a),
+		PTR:         netaddr.PTR(a),
+	}
+
+	if a.Is4() {
+		cr.Mapped = netip.AddrFrom16(a.As16()).String()
+	}
+
+	if a.Is4In6() {
+		cr.Unmapped = a.Unmap().String()
+	}
+
+	return cr, nil
+}
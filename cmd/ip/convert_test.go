@@ -0,0 +1,67 @@
+package ip
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		to     addrFormat
+		output string
+		err    bool
+	}{
+		{"3221225985", addrFormatCanonical, "192.0.2.1", false},
+		{"192.0.2.1", addrFormatMapped, "::ffff:192.0.2.1", false},
+		{"::ffff:192.0.2.1", addrFormatMapped, "::ffff:192.0.2.1", false},
+		{"2001:db8::1", addrFormatMapped, "", true},
+		{"192.0.2.1", addrFormatExpanded, "", true},
+		{"2001:db8::1", addrFormatPTR, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip+"/"+string(tt.to), func(t *testing.T) {
+			cr, err := convertIP(tt.ip, false)
+			if err != nil {
+				t.Fatalf("convertIP returned an error when one wasn't expected: %+v", err)
+			}
+
+			got, err := cr.format(tt.to)
+			if tt.err {
+				if err == nil {
+					t.Errorf("format didn't return an error when one was expected")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("format returned an error when one wasn't expected: %+v", err)
+			}
+
+			if got != tt.output {
+				t.Errorf("expected %s but got %s", tt.output, got)
+			}
+		})
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		cr, err := convertIP("::ffff:192.0.2.1", false)
+		if err != nil {
+			t.Fatalf("convertIP returned an error when one wasn't expected: %+v", err)
+		}
+
+		var sb strings.Builder
+
+		if err := cr.writeText(&sb); err != nil {
+			t.Fatalf("writeText returned an error when one wasn't expected: %+v", err)
+		}
+
+		for _, want := range []string{"IP: ::ffff:192.0.2.1\n", "Family:                 IPv6\n", "Expanded:               0000:0000:0000:0000:0000:ffff:c000:0201\n", "IPv4:                   192.0.2.1\n"} {
+			if !strings.Contains(sb.String(), want) {
+				t.Errorf("expected the output to contain %q, got:\n%s", want, sb.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package ip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/simondrake/genc/internal/netaddr"
+)
+
+// addrDetails describes the forms of an address, as written by ip info and
+// ip convert.
+type addrDetails struct {
+	IP        string `json:"ip"`
+	Family    string `json:"family"`
+	Canonical string `json:"canonical"`
+	Expanded  string `json:"expanded,omitempty"`
+	Integer   string `json:"integer"`
+	Hex       string `json:"hex"`
+	Binary    string `json:"binary"`
+}
+
+// describeAddr returns the forms of a, which was parsed from ip.
+func describeAddr(ip string, a netip.Addr) addrDetails {
+	d := addrDetails{
+		IP:        ip,
+		Family:    netaddr.Family(a),
+		Canonical: a.String(),
+		Integer:   netaddr.ToInt(a).String(),
+		Hex:       netaddr.Hex(a),
+		Binary:    netaddr.Binary(a),
+	}
+
+	if a.Is6() {
+		d.Expanded = a.WithZone("").StringExpanded()
+	}
+
+	return d
+}
+
+// writeFields writes the heading and forms of the address to sb, for the
+// caller to follow with its own fields.
+func (d *addrDetails) writeFields(sb *strings.Builder) {
+	fmt.Fprintln(sb)
+	fmt.Fprintf(sb, "IP: %s\n", d.IP)
+	fmt.Fprintln(sb, "------------------------")
+	field(sb, "Family", d.Family)
+	field(sb, "Canonical", d.Canonical)
+
+	if d.Expanded != "" {
+		field(sb, "Expanded", d.Expanded)
+	}
+
+	field(sb, "Integer", d.Integer)
+	field(sb, "Hex", d.Hex)
+	field(sb, "Binary", d.Binary)
+}
+
+func field(sb *strings.Builder, label string, value interface{}) {
+	fmt.Fprintf(sb, "%-24s%v\n", label+":", value)
+}
@@ -0,0 +1,286 @@
+package ip
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newExtractCommand() *cobra.Command {
+	var (
+		cidrs    []string
+		cidrFile string
+		opts     extractOptions
+	)
+
+	format := output.Text
+
+	extractCmd := &cobra.Command{
+		Use:   "extract [file]...",
+		Short: "extract IP addresses from text, such as logs",
+		Long: `extract IP addresses from text, such as logs, read from files or stdin
+
+IPv4 and IPv6 addresses are found anywhere in the text, including in bracketed ([2001:db8::1]:443) and port suffixed
+(192.0.2.1:8080) forms. The addresses are written once each, sorted numerically with IPv4 before IPv6, and can be
+annotated with whether they're public or private, and which of a list of CIDRs they're in.`,
+		Example: `
+    $ echo 'accepted from 192.0.2.1:8080 and [2001:db8::1]:443, then 10.0.0.7 and 192.0.2.1' | genc ip extract
+    10.0.0.7
+    192.0.2.1
+    2001:db8::1
+
+    $ genc ip extract /var/log/auth.log --classify --count
+    IP         COUNT  CLASS
+    8.8.8.8    1      public
+    10.0.0.7   12     private
+    192.0.2.1  3      private
+
+    $ genc ip extract access.log --cidr-file blocked.txt --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				if !input.Piped() {
+					fmt.Fprintln(os.Stderr, errors.New("text is required, in a file or on stdin"))
+					os.Exit(1)
+				}
+
+				args = []string{"-"}
+			}
+
+			if len(cidrs) > 0 || cidrFile != "" {
+				prefixes, err := input.PrefixList(cidrs, cidrFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
+					os.Exit(1)
+				}
+
+				opts.cidrs = &netaddr.Trie{}
+
+				for _, p := range prefixes {
+					opts.cidrs.Insert(p)
+				}
+			}
+
+			found := make(map[netip.Addr]int)
+
+			for _, path := range args {
+				if err := extractFile(path, found); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error extracting IPs from '%s': %w", path, err))
+					os.Exit(1)
+				}
+			}
+
+			ers := extractResults(found, opts)
+
+			var err error
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, ers)
+			} else {
+				err = writeExtracted(os.Stdout, ers, opts)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	extractCmd.Flags().BoolVar(&opts.classify, "classify", false, "classify each IP as public or private")
+	extractCmd.Flags().BoolVar(&opts.count, "count", false, "include the number of times each IP was found")
+	extractCmd.Flags().StringArrayVar(&cidrs, "cidr", nil, "a cidr, or JSON list of cidrs, to match each IP against, can be repeated")
+	extractCmd.Flags().StringVar(&cidrFile, "cidr-file", "", "the location of a file on disk containing cidrs to match each IP against, one per line")
+	extractCmd.Flags().Var(&format, "output", "the output format")
+
+	return extractCmd
+}
+
+type extractOptions struct {
+	classify bool
+	count    bool
+	cidrs    *netaddr.Trie
+}
+
+type extractResult struct {
+	IP    netip.Addr     `json:"ip"`
+	Count int            `json:"count"`
+	Class string         `json:"class,omitempty"`
+	CIDRs []netip.Prefix `json:"cidrs,omitempty"`
+}
+
+// extractFile adds the IPs found in the file at path, or stdin if path is
+// "-", to found, counting the number of times each is seen.
+func extractFile(path string, found map[netip.Addr]int) error {
+	r, err := input.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	for s.Scan() {
+		for _, a := range extractIPs(s.Text()) {
+			found[a]++
+		}
+	}
+
+	return s.Err()
+}
+
+// extractIPs returns the IP addresses in text, in the order they appear.
+//
+// The text is split into words of the characters that can make up an address,
+// so brackets, commas, quotes and so on separate them. Letters are kept in the
+// words, so that hex-like fragments of other words, such as the d::ec in
+// std::vector, aren't mistaken for IPv6 addresses.
+func extractIPs(text string) []netip.Addr {
+	var ips []netip.Addr
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '.' || r == ':' || r == '%')
+	})
+
+	for _, w := range words {
+		// Addresses at the end of a sentence, or followed by a colon
+		w = strings.TrimRight(w, ".:")
+
+		if !strings.ContainsAny(w, ".:") {
+			continue
+		}
+
+		if a, ok := parseExtracted(w); ok {
+			ips = append(ips, a)
+		}
+	}
+
+	return ips
+}
+
+// parseExtracted parses w as an IP address, or an IPv4 address followed by a
+// port, dropping any IPv6 zone.
+//
+// If w doesn't parse, it's retried without its leading label, as in
+// src:10.0.0.1, for as long as it starts with one.
+func parseExtracted(w string) (netip.Addr, bool) {
+	for {
+		if a, err := netip.ParseAddr(w); err == nil {
+			return a.WithZone(""), true
+		}
+
+		if ap, err := netip.ParseAddrPort(w); err == nil && ap.Addr().Is4() {
+			return ap.Addr(), true
+		}
+
+		label, rest, found := strings.Cut(w, ":")
+		if !found || !isLabel(label) {
+			return netip.Addr{}, false
+		}
+
+		w = rest
+	}
+}
+
+// isLabel returns whether s is a letter followed by any number of letters and
+// digits, such as src or ip6.
+func isLabel(s string) bool {
+	for i, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// extractResults returns the IPs in found, sorted numerically, annotated as set
+// by opts.
+func extractResults(found map[netip.Addr]int, opts extractOptions) []extractResult {
+	ers := make([]extractResult, 0, len(found))
+
+	for a, n := range found {
+		er := extractResult{IP: a, Count: n}
+
+		if opts.classify {
+			er.Class = "private"
+			if netaddr.IsGlobal(a) {
+				er.Class = "public"
+			}
+		}
+
+		if opts.cidrs != nil {
+			er.CIDRs = opts.cidrs.Lookup(a)
+		}
+
+		ers = append(ers, er)
+	}
+
+	sort.Slice(ers, func(i, j int) bool {
+		return ers[i].IP.Less(ers[j].IP)
+	})
+
+	return ers
+}
+
+// writeExtracted writes the IPs in ers to w, one per line, or as a table if
+// they're annotated.
+func writeExtracted(w io.Writer, ers []extractResult, opts extractOptions) error {
+	if !opts.classify && !opts.count && opts.cidrs == nil {
+		for _, er := range ers {
+			if _, err := fmt.Fprintln(w, er.IP); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := []string{"IP"}
+	if opts.count {
+		header = append(header, "COUNT")
+	}
+
+	if opts.classify {
+		header = append(header, "CLASS")
+	}
+
+	if opts.cidrs != nil {
+		header = append(header, "CIDRS")
+	}
+
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, er := range ers {
+		row := []string{er.IP.String()}
+		if opts.count {
+			row = append(row, fmt.Sprint(er.Count))
+		}
+
+		if opts.classify {
+			row = append(row, er.Class)
+		}
+
+		if opts.cidrs != nil {
+			row = append(row, joinPrefixes(er.CIDRs))
+		}
+
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
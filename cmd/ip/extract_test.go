@@ -0,0 +1,58 @@
+package ip
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestExtractIPs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		ips  []string
+	}{
+		{"IPv4", "Accepted publickey for root from 192.0.2.1 port 22", []string{"192.0.2.1"}},
+		{"Port", "connect to 192.0.2.1:8080 failed", []string{"192.0.2.1"}},
+		{"Bracketed", "GET https://[2001:db8::1]:443/ from [::1]", []string{"2001:db8::1", "::1"}},
+		{"Zone", "neighbour fe80::1%eth0 reachable", []string{"fe80::1"}},
+		{"Punctuation", `{"src":"10.0.0.7","dst":"10.0.0.8"}, then 10.0.0.9.`, []string{"10.0.0.7", "10.0.0.8", "10.0.0.9"}},
+		{"Mapped", "client ::ffff:192.0.2.1 connected", []string{"::ffff:192.0.2.1"}},
+		{"Labelled", "src:10.0.0.1 dst:10.0.0.2:443 ip:192.0.2.5 client:10.0.0.9 addr:2001:db8::1", []string{"10.0.0.1", "10.0.0.2", "192.0.2.5", "10.0.0.9", "2001:db8::1"}},
+		{"LabelledTwice", "peer:ip:192.0.2.6", []string{"192.0.2.6"}},
+		{"NotAddresses", "std::vector at 12:30:01 from 00:1a:2b:3c:4d:5e version 1.2.3 or 999.1.1.1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range extractIPs(tt.text) {
+				got = append(got, a.String())
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.ips, ",") {
+				t.Errorf("expected %v but got %v", tt.ips, got)
+			}
+		})
+	}
+}
+
+func TestWriteExtracted(t *testing.T) {
+	found := make(map[netip.Addr]int)
+
+	for _, a := range extractIPs("2001:db8::1 10.0.0.7 8.8.8.8 10.0.0.7") {
+		found[a]++
+	}
+
+	var sb strings.Builder
+
+	if err := writeExtracted(&sb, extractResults(found, extractOptions{classify: true, count: true}), extractOptions{classify: true, count: true}); err != nil {
+		t.Fatalf("writeExtracted returned an error when one wasn't expected: %+v", err)
+	}
+
+	want := "IP           COUNT  CLASS\n8.8.8.8      1      public\n10.0.0.7     2      private\n2001:db8::1  1      private\n"
+
+	if sb.String() != want {
+		t.Errorf("expected %q but got %q", want, sb.String())
+	}
+}
@@ -1,61 +1,267 @@
 package ip
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
+	"io"
+	"net/netip"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
 )
 
+// maxLineLength is the longest line read when checking a stream of IPs, which
+// is generous enough for access log lines.
+const maxLineLength = 1024 * 1024
+
 func newInCIDRCommand() *cobra.Command {
 	var (
-		ip   string
-		cidr string
+		ips      []string
+		file     string
+		cidrs    []string
+		cidrFile string
+		matched  bool
 	)
 
+	format := output.Text
+
 	inCIDRCmd := &cobra.Command{
 		Use:   "inCIDR",
 		Short: "determine if an IP is within the range of a CIDR",
+		Long: `determine if an IP is within the range of a CIDR, or which of a list of CIDRs it's within
+
+IPs can be provided with --ip, or streamed from a file or stdin, one per line. When streaming, only the first field of
+each line is used, so access logs can be checked directly, and each IP is written with the CIDRs it matched, or none.
+Lines that don't start with a valid IP are reported on stderr.
+
+--output json writes one JSON object per IP, on its own line.`,
 		Example: `
     $ genc ip inCIDR --ip "192.168.1.68" --cidr "192.168.1.0/24"
     IP (192.168.1.68) is in the CIDR range (192.168.1.0/24)
 
     $ genc ip inCIDR --ip "192.168.1.68" --cidr "192.168.1.30/32"
-    IP (192.168.1.68) is not in the CIDR range (192.168.1.30/32)`,
+    IP (192.168.1.68) is not in the CIDR range (192.168.1.30/32)
+
+    $ awk '{print $1}' access.log | genc ip inCIDR --cidr '["10.0.0.0/8", "192.168.0.0/16"]' --cidr 192.168.1.0/24
+    10.1.2.3 10.0.0.0/8
+    192.168.1.68 192.168.1.0/24,192.168.0.0/16
+    203.0.113.9 none
+
+    # Only output the requests from a list of blocked ranges
+    $ genc ip inCIDR --file access.log --cidr-file blocked.txt --matched`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ic, err := ipInCIDR(ip, cidr)
+			prefixes, err := readCIDRs(cidrs, cidrFile)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("error determining if IP is in CIDR: %w", err))
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading CIDRs: %w", err))
 				os.Exit(1)
 			}
 
-			if ic {
-				fmt.Fprintf(os.Stdout, "IP (%s) is in the CIDR range (%s)", ip, cidr)
-			} else {
-				fmt.Fprintf(os.Stdout, "IP (%s) is not in the CIDR range (%s)", ip, cidr)
+			var t netaddr.Trie
+
+			for _, p := range prefixes {
+				t.Insert(p)
+			}
+
+			if len(ips) > 0 {
+				for _, ip := range ips {
+					m, err := matchIP(&t, ip)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, fmt.Errorf("error determining if IP is in CIDR: %w", err))
+						os.Exit(1)
+					}
+
+					if format == output.JSON {
+						err = json.NewEncoder(os.Stdout).Encode(matchResult{IP: ip, CIDRs: m})
+					} else {
+						err = writeMatch(os.Stdout, ip, m, prefixes)
+					}
+
+					if err != nil {
+						fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+						os.Exit(1)
+					}
+				}
+
+				return
+			}
+
+			if file == "" {
+				if !input.Piped() {
+					fmt.Fprintln(os.Stderr, errors.New("an IP is required, with --ip or --file, or on stdin"))
+					os.Exit(1)
+				}
+
+				file = "-"
+			}
+
+			r, err := input.Open(file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading IPs: %w", err))
+				os.Exit(1)
+			}
+			defer r.Close()
+
+			invalid, err := matchStream(r, os.Stdout, os.Stderr, &t, streamOptions{matched: matched, format: format})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error checking IPs: %w", err))
+				os.Exit(1)
+			}
+
+			if invalid > 0 {
+				fmt.Fprintf(os.Stderr, "lines without a valid IP: %d\n", invalid)
+				os.Exit(1)
 			}
 		},
 	}
 
-	inCIDRCmd.Flags().StringVar(&ip, "ip", "", "the ip address")
-	inCIDRCmd.Flags().StringVar(&cidr, "cidr", "", "the cidr")
+	inCIDRCmd.Flags().StringArrayVar(&ips, "ip", nil, "the ip address, can be repeated")
+	inCIDRCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing IPs, one per line, or - to read from stdin")
+	inCIDRCmd.Flags().StringArrayVar(&cidrs, "cidr", nil, "the cidr, or JSON list of cidrs, can be repeated")
+	inCIDRCmd.Flags().StringVar(&cidrFile, "cidr-file", "", "the location of a file on disk containing cidrs, one per line")
+	inCIDRCmd.Flags().BoolVar(&matched, "matched", false, "only output IPs that are in at least one cidr, when reading from a file or stdin")
+	inCIDRCmd.Flags().Var(&format, "output", "the output format")
 
-	if err := inCIDRCmd.MarkFlagRequired("ip"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'ip' as required: %w", err))
+	inCIDRCmd.MarkFlagsMutuallyExclusive("ip", "file")
+	inCIDRCmd.MarkFlagsOneRequired("cidr", "cidr-file")
+
+	return inCIDRCmd
+}
+
+type matchResult struct {
+	IP    string         `json:"ip"`
+	CIDRs []netip.Prefix `json:"cidrs"`
+	Line  int            `json:"line,omitempty"`
+}
+
+// readCIDRs reads the CIDRs, or JSON lists of CIDRs, in cidrs and the file at
+// cidrFile. CIDRs with host bits set, such as 192.168.1.5/24, are treated as
+// the block they are in.
+func readCIDRs(cidrs []string, cidrFile string) ([]netip.Prefix, error) {
+	prefixes, err := input.HostPrefixList(cidrs, cidrFile)
+	if err != nil {
+		return nil, err
 	}
-	if err := inCIDRCmd.MarkFlagRequired("cidr"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'cidr' as required: %w", err))
+
+	for i, p := range prefixes {
+		prefixes[i] = p.Masked()
 	}
 
-	return inCIDRCmd
+	return prefixes, nil
 }
 
-func ipInCIDR(ip, cidr string) (bool, error) {
-	_, n, err := net.ParseCIDR(cidr)
+// matchIP returns the CIDRs in t that contain ip, most specific first.
+func matchIP(t *netaddr.Trie, ip string) ([]netip.Prefix, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(ip))
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	m := t.Lookup(a)
+	if m == nil {
+		m = []netip.Prefix{}
+	}
+
+	return m, nil
+}
+
+// writeMatch writes whether ip is in each of the matched CIDRs, or in none of
+// prefixes.
+func writeMatch(w io.Writer, ip string, matched, prefixes []netip.Prefix) error {
+	var err error
+
+	switch {
+	case len(matched) > 0:
+		for _, p := range matched {
+			if _, err = fmt.Fprintf(w, "IP (%s) is in the CIDR range (%s)\n", ip, p); err != nil {
+				break
+			}
+		}
+	case len(prefixes) == 1:
+		_, err = fmt.Fprintf(w, "IP (%s) is not in the CIDR range (%s)\n", ip, prefixes[0])
+	default:
+		_, err = fmt.Fprintf(w, "IP (%s) is not in any of the CIDR ranges\n", ip)
+	}
+
+	return err
+}
+
+type streamOptions struct {
+	matched bool
+	format  output.Format
+}
+
+// matchStream checks the IP at the start of each line read from r against t,
+// writing the results to w and invalid lines to errW. The number of invalid
+// lines is returned.
+func matchStream(r io.Reader, w, errW io.Writer, t *netaddr.Trie, opts streamOptions) (int, error) {
+	var invalid int
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		m, err := matchIP(t, fields[0])
+		if err != nil {
+			fmt.Fprintln(errW, fmt.Errorf("error parsing IP '%s' on line %d: %w", fields[0], line, err))
+			invalid++
+
+			continue
+		}
+
+		if opts.matched && len(m) == 0 {
+			continue
+		}
+
+		if opts.format == output.JSON {
+			err = enc.Encode(matchResult{IP: fields[0], CIDRs: m, Line: line})
+		} else {
+			err = writeMatchLine(bw, fields[0], m)
+		}
+
+		if err != nil {
+			return invalid, err
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return invalid, err
+	}
+
+	return invalid, bw.Flush()
+}
+
+// writeMatchLine writes ip followed by the CIDRs it matched.
+func writeMatchLine(w io.Writer, ip string, matched []netip.Prefix) error {
+	_, err := fmt.Fprintln(w, ip, joinPrefixes(matched))
+
+	return err
+}
+
+// joinPrefixes returns prefixes separated by commas, or none if it's empty.
+func joinPrefixes(prefixes []netip.Prefix) string {
+	if len(prefixes) == 0 {
+		return "none"
+	}
+
+	s := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		s[i] = p.String()
 	}
 
-	return n.Contains(net.ParseIP(ip)), nil
+	return strings.Join(s, ",")
 }
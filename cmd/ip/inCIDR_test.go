@@ -0,0 +1,89 @@
+package ip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func TestMatchStream(t *testing.T) {
+	var trie netaddr.Trie
+
+	for _, c := range []string{"10.0.0.0/8", "192.168.0.0/16", "192.168.1.0/24"} {
+		p, err := netaddr.ParsePrefix(c)
+		if err != nil {
+			t.Fatalf("ParsePrefix returned an error when one wasn't expected: %+v", err)
+		}
+
+		trie.Insert(p)
+	}
+
+	lines := "10.1.2.3 - - [19/Oct/2026:10:00:00 +0000] \"GET / HTTP/1.1\" 200\n\n192.168.1.68\n203.0.113.9\nwibble\n"
+
+	tests := []struct {
+		name   string
+		opts   streamOptions
+		output string
+	}{
+		{"All", streamOptions{format: output.Text}, "10.1.2.3 10.0.0.0/8\n192.168.1.68 192.168.1.0/24,192.168.0.0/16\n203.0.113.9 none\n"},
+		{"Matched", streamOptions{matched: true, format: output.Text}, "10.1.2.3 10.0.0.0/8\n192.168.1.68 192.168.1.0/24,192.168.0.0/16\n"},
+		{"JSON", streamOptions{matched: true, format: output.JSON}, "{\"ip\":\"10.1.2.3\",\"cidrs\":[\"10.0.0.0/8\"],\"line\":1}\n{\"ip\":\"192.168.1.68\",\"cidrs\":[\"192.168.1.0/24\",\"192.168.0.0/16\"],\"line\":3}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w, errW strings.Builder
+
+			invalid, err := matchStream(strings.NewReader(lines), &w, &errW, &trie, tt.opts)
+			if err != nil {
+				t.Fatalf("matchStream returned an error when one wasn't expected: %+v", err)
+			}
+
+			if w.String() != tt.output {
+				t.Errorf("expected %q but got %q", tt.output, w.String())
+			}
+
+			if invalid != 1 || !strings.Contains(errW.String(), "'wibble' on line 5") {
+				t.Errorf("expected line 5 to be reported as invalid, but got %d invalid lines: %s", invalid, errW.String())
+			}
+		})
+	}
+}
+
+func TestReadCIDRs(t *testing.T) {
+	prefixes, err := readCIDRs([]string{"192.168.1.5/24", `["10.0.0.0/8", "2001:db8::1/32"]`}, "")
+	if err != nil {
+		t.Fatalf("readCIDRs returned an error when one wasn't expected: %+v", err)
+	}
+
+	var trie netaddr.Trie
+
+	for _, p := range prefixes {
+		trie.Insert(p)
+	}
+
+	m, err := matchIP(&trie, "192.168.1.68")
+	if err != nil {
+		t.Fatalf("matchIP returned an error when one wasn't expected: %+v", err)
+	}
+
+	if len(m) != 1 || m[0].String() != "192.168.1.0/24" {
+		t.Errorf("expected 192.168.1.68 to be in 192.168.1.0/24, got %v", m)
+	}
+
+	var sb strings.Builder
+
+	if err := writeMatch(&sb, "192.168.2.1", nil, prefixes[:1]); err != nil {
+		t.Fatalf("writeMatch returned an error when one wasn't expected: %+v", err)
+	}
+
+	if want := "IP (192.168.2.1) is not in the CIDR range (192.168.1.0/24)\n"; sb.String() != want {
+		t.Errorf("expected %q but got %q", want, sb.String())
+	}
+
+	if _, err := readCIDRs([]string{"192.168.1.5"}, ""); err == nil {
+		t.Errorf("readCIDRs didn't return an error for an address without a prefix length")
+	}
+}
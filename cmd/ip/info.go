@@ -0,0 +1,132 @@
+package ip
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/netaddr"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newInfoCommand() *cobra.Command {
+	var ip string
+
+	format := output.Text
+
+	infoCmd := &cobra.Command{
+		Use:   "info",
+		Short: "output information about an IP address, including any special-purpose registries it's in",
+		Example: `
+    $ genc ip info --ip 100.64.12.7
+
+    IP: 100.64.12.7
+    ------------------------
+    Family:                 IPv4
+    Canonical:              100.64.12.7
+    Integer:                1681918983
+    Hex:                    0x64400c07
+    Binary:                 01100100.01000000.00001100.00000111
+    Globally Reachable:     no
+    Special Purpose:        Shared Address Space (CGNAT) - 100.64.0.0/10 (RFC 6598)
+
+    $ genc ip info --ip 2002:c000:0204::1 --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ir, err := ipInfo(ip)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing IP '%s': %w", ip, err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, ir)
+			} else {
+				err = ir.writeText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	infoCmd.Flags().StringVar(&ip, "ip", "", "the ip address")
+	infoCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := infoCmd.MarkFlagRequired("ip"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'ip' as required: %w", err))
+	}
+
+	return infoCmd
+}
+
+type infoResponse struct {
+	addrDetails
+	EmbeddedIPv4   string             `json:"embeddedIPv4,omitempty"`
+	Global         bool               `json:"globallyReachable"`
+	SpecialPurpose []netaddr.Registry `json:"specialPurpose"`
+}
+
+func (ir *infoResponse) writeText(w io.Writer) error {
+	var sb strings.Builder
+
+	ir.writeFields(&sb)
+
+	if ir.EmbeddedIPv4 != "" {
+		field(&sb, "Embedded IPv4", ir.EmbeddedIPv4)
+	}
+
+	if ir.Global {
+		field(&sb, "Globally Reachable", "yes")
+	} else {
+		field(&sb, "Globally Reachable", "no")
+	}
+
+	if len(ir.SpecialPurpose) == 0 {
+		field(&sb, "Special Purpose", "none")
+	}
+
+	for i, r := range ir.SpecialPurpose {
+		// Further registries are aligned under the first
+		label := "Special Purpose:"
+		if i > 0 {
+			label = ""
+		}
+
+		fmt.Fprintf(&sb, "%-24s%s - %s (%s)\n", label, r.Name, r.Prefix, r.RFC)
+	}
+
+	fmt.Fprintln(&sb)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func ipInfo(ip string) (*infoResponse, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return nil, err
+	}
+
+	ir := &infoResponse{
+		addrDetails:    describeAddr(ip, a),
+		Global:         netaddr.IsGlobal(a),
+		SpecialPurpose: netaddr.SpecialPurpose(a),
+	}
+
+	if v4, ok := netaddr.EmbeddedIPv4(a); ok {
+		ir.EmbeddedIPv4 = v4.String()
+	}
+
+	if ir.SpecialPurpose == nil {
+		ir.SpecialPurpose = []netaddr.Registry{}
+	}
+
+	return ir, nil
+}
@@ -11,6 +11,12 @@ func NewCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(newInCIDRCommand())
+	cmd.AddCommand(newInfoCommand())
+	cmd.AddCommand(newExtractCommand())
+	cmd.AddCommand(newConvertCommand())
+	cmd.AddCommand(newAddCommand())
+	cmd.AddCommand(newDiffCommand())
+	cmd.AddCommand(newLookupCommand())
 
 	return cmd
 }
@@ -0,0 +1,287 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/input"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newLookupCommand() *cobra.Command {
+	var (
+		ips  []string
+		file string
+		dbs  []string
+		lang string
+	)
+
+	format := output.Text
+
+	lookupCmd := &cobra.Command{
+		Use:   "lookup [ip]...",
+		Short: "look up the location and network of IP addresses in MaxMind databases",
+		Long: `look up the location and network of IP addresses in local MaxMind (MMDB) databases, such as GeoLite2-City and
+GeoLite2-ASN, without any network access
+
+IPs can be provided as arguments, with --ip, or one per line in a file or on stdin. --db can be repeated to combine, for
+example, a city and an ASN database, and a table is written if more than one IP is looked up. IPs that can't be
+looked up are reported on stderr, and the rest are still written, with an exit code of 1.`,
+		Example: `
+    $ genc ip lookup --db GeoLite2-City.mmdb --db GeoLite2-ASN.mmdb --ip 81.2.69.142
+
+    IP: 81.2.69.142
+    ------------------------
+    Network:                81.2.69.142/31
+    Country:                United Kingdom (GB)
+    City:                   London
+    ASN:                    20712
+    Organization:           Andrews & Arnold Ltd
+
+    $ genc ip extract suspicious.log | genc ip lookup --db GeoLite2-City.mmdb --db GeoLite2-ASN.mmdb
+    IP           COUNTRY  CITY    ASN    ORGANIZATION
+    81.2.69.142  GB       London  20712  Andrews & Arnold Ltd
+    ...`,
+		Run: func(cmd *cobra.Command, args []string) {
+			list, err := input.Read(append(ips, args...), file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading IPs: %w", err))
+				os.Exit(1)
+			}
+
+			if len(list) == 0 {
+				fmt.Fprintln(os.Stderr, errors.New("at least one IP is required, as an argument or with --ip or --file"))
+				os.Exit(1)
+			}
+
+			readers := make([]*maxminddb.Reader, len(dbs))
+
+			for i, db := range dbs {
+				if readers[i], err = maxminddb.Open(db); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error opening database '%s': %w", db, err))
+					os.Exit(1)
+				}
+				defer readers[i].Close()
+			}
+
+			lrs, failed := lookupIPs(readers, list, lang, os.Stderr)
+
+			switch {
+			case format == output.JSON:
+				err = output.WriteJSON(os.Stdout, lrs)
+			case len(list) == 1 && len(lrs) == 1:
+				err = lrs[0].writeText(os.Stdout)
+			case len(lrs) > 0:
+				err = writeLookupTable(os.Stdout, lrs)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+
+			if failed > 0 {
+				if len(list) > 1 {
+					fmt.Fprintf(os.Stderr, "IPs that couldn't be looked up: %d\n", failed)
+				}
+
+				os.Exit(1)
+			}
+		},
+	}
+
+	lookupCmd.Flags().StringArrayVar(&ips, "ip", nil, "the ip address, can be repeated")
+	lookupCmd.Flags().StringVar(&file, "file", "", "the location of a file on disk containing IPs, one per line, or - to read from stdin")
+	lookupCmd.Flags().StringArrayVar(&dbs, "db", nil, "the location of a MaxMind database on disk, can be repeated")
+	lookupCmd.Flags().StringVar(&lang, "lang", "en", "the language of country and city names")
+	lookupCmd.Flags().Var(&format, "output", "the output format")
+
+	if err := lookupCmd.MarkFlagRequired("db"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'db' as required: %w", err))
+	}
+
+	return lookupCmd
+}
+
+// mmdbRecord holds the fields used from the GeoIP2 and GeoLite2 City,
+// Country and ASN databases.
+type mmdbRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+type lookupResponse struct {
+	IP           netip.Addr     `json:"ip"`
+	Networks     []netip.Prefix `json:"networks"`
+	Country      string         `json:"country,omitempty"`
+	CountryCode  string         `json:"countryCode,omitempty"`
+	City         string         `json:"city,omitempty"`
+	ASN          uint           `json:"asn,omitempty"`
+	Organization string         `json:"organization,omitempty"`
+}
+
+func (lr *lookupResponse) writeText(w io.Writer) error {
+	var sb strings.Builder
+
+	fmt.Fprintln(&sb)
+	fmt.Fprintf(&sb, "IP: %s\n", lr.IP)
+	fmt.Fprintln(&sb, "------------------------")
+
+	if len(lr.Networks) == 0 {
+		field(&sb, "Network", "not found")
+	}
+
+	for _, n := range lr.Networks {
+		field(&sb, "Network", n)
+	}
+
+	if lr.Country != "" || lr.CountryCode != "" {
+		field(&sb, "Country", fmt.Sprintf("%s (%s)", lr.Country, lr.CountryCode))
+	}
+
+	if lr.City != "" {
+		field(&sb, "City", lr.City)
+	}
+
+	if lr.ASN != 0 {
+		field(&sb, "ASN", lr.ASN)
+	}
+
+	if lr.Organization != "" {
+		field(&sb, "Organization", lr.Organization)
+	}
+
+	fmt.Fprintln(&sb)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func writeLookupTable(w io.Writer, lrs []*lookupResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "IP\tCOUNTRY\tCITY\tASN\tORGANIZATION")
+
+	for _, lr := range lrs {
+		asn := ""
+		if lr.ASN != 0 {
+			asn = fmt.Sprint(lr.ASN)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", lr.IP, orDash(lr.CountryCode), orDash(lr.City), orDash(asn), orDash(lr.Organization))
+	}
+
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// lookupIPs looks up each of ips in readers, writing those that can't be
+// looked up to errW and carrying on. The number that couldn't be looked up is
+// returned.
+func lookupIPs(readers []*maxminddb.Reader, ips []string, lang string, errW io.Writer) ([]*lookupResponse, int) {
+	lrs := []*lookupResponse{}
+
+	var failed int
+
+	for _, ip := range ips {
+		lr, err := lookupIP(readers, ip, lang)
+		if err != nil {
+			fmt.Fprintln(errW, fmt.Errorf("error looking up IP '%s': %w", ip, err))
+			failed++
+
+			continue
+		}
+
+		lrs = append(lrs, lr)
+	}
+
+	return lrs, failed
+}
+
+// lookupIP looks up ip in each of readers, combining the fields found. Names
+// are in lang, or English if they aren't available in lang.
+func lookupIP(readers []*maxminddb.Reader, ip, lang string) (*lookupResponse, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return nil, err
+	}
+
+	a = a.Unmap().WithZone("")
+
+	lr := &lookupResponse{IP: a, Networks: []netip.Prefix{}}
+
+	for _, r := range readers {
+		if a.Is6() && r.Metadata.IPVersion == 4 {
+			// IPv4 only databases can't hold IPv6 addresses
+			continue
+		}
+
+		var rec mmdbRecord
+
+		network, ok, err := r.LookupNetwork(net.IP(a.AsSlice()), &rec)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		// Each database can have a different network for the same IP, so each
+		// is recorded, once
+		if n, ok := netip.AddrFromSlice(network.IP); ok {
+			bits, _ := network.Mask.Size()
+			if p := netip.PrefixFrom(n.Unmap(), bits); !slices.Contains(lr.Networks, p) {
+				lr.Networks = append(lr.Networks, p)
+			}
+		}
+
+		if rec.Country.ISOCode != "" {
+			lr.CountryCode, lr.Country = rec.Country.ISOCode, name(rec.Country.Names, lang)
+		}
+
+		if c := name(rec.City.Names, lang); c != "" {
+			lr.City = c
+		}
+
+		if rec.ASN != 0 {
+			lr.ASN, lr.Organization = rec.ASN, rec.Organization
+		}
+	}
+
+	return lr, nil
+}
+
+// name returns the name in lang, falling back to English.
+func name(names map[string]string, lang string) string {
+	if n, ok := names[lang]; ok {
+		return n
+	}
+
+	return names["en"]
+}
@@ -0,0 +1,83 @@
+package ip
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// The test databases hold the documentation prefixes, in the layout of the
+// GeoLite2 City and ASN databases:
+//
+//	192.0.2.0/24     GB, London, AS64496 Example Networks
+//	198.51.100.0/25  DE, Berlin
+//	203.0.113.0/24   US
+//	2001:db8::/32    FR, Paris, AS64511 Documentation Inc
+func TestLookupIP(t *testing.T) {
+	var readers []*maxminddb.Reader
+
+	for _, db := range []string{"test-city.mmdb", "test-asn.mmdb"} {
+		r, err := maxminddb.Open(filepath.Join("testdata", db))
+		if err != nil {
+			t.Fatalf("Open returned an error when one wasn't expected: %+v", err)
+		}
+		defer r.Close()
+
+		readers = append(readers, r)
+	}
+
+	tests := []struct {
+		ip       string
+		lang     string
+		networks []string
+		country  string
+		city     string
+		asn      uint
+	}{
+		{"192.0.2.77", "en", []string{"192.0.2.0/24"}, "United Kingdom", "London", 64496},
+		{"198.51.100.1", "de", []string{"198.51.100.0/25"}, "Deutschland", "Berlin", 0},
+		{"::ffff:203.0.113.1", "fr", []string{"203.0.113.0/24"}, "United States", "", 0},
+		{"2001:db8::5", "en", []string{"2001:db8::/32"}, "France", "Paris", 64511},
+		{"198.51.100.200", "en", nil, "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			lr, err := lookupIP(readers, tt.ip, tt.lang)
+			if err != nil {
+				t.Fatalf("lookupIP returned an error when one wasn't expected: %+v", err)
+			}
+
+			var networks []string
+			for _, n := range lr.Networks {
+				networks = append(networks, n.String())
+			}
+
+			if !reflect.DeepEqual(networks, tt.networks) || lr.Country != tt.country || lr.City != tt.city || lr.ASN != tt.asn {
+				t.Errorf("expected %v %q %q %d but got %v %q %q %d", tt.networks, tt.country, tt.city, tt.asn, networks, lr.Country, lr.City, lr.ASN)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := lookupIP(readers, "wibble", "en"); err == nil {
+			t.Errorf("lookupIP was expected to return an error but didn't")
+		}
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+		var errW strings.Builder
+
+		lrs, failed := lookupIPs(readers, []string{"192.0.2.1", "bogus", "2001:db8::1"}, "en", &errW)
+		if failed != 1 || !strings.Contains(errW.String(), "'bogus'") {
+			t.Errorf("expected 'bogus' to be reported, but got %d failures: %s", failed, errW.String())
+		}
+
+		if len(lrs) != 2 || lrs[0].IP.String() != "192.0.2.1" || lrs[1].IP.String() != "2001:db8::1" {
+			t.Errorf("expected the valid IPs to be looked up, got %+v", lrs)
+		}
+	})
+}
@@ -1,22 +1,28 @@
 package jwt
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/keys"
 )
 
 func newCreateCommand() *cobra.Command {
 	var (
-		signingKey string
-		claims     string
+		signingKey     string
+		privateKey     string
+		passphraseFile string
+		claims         string
+		method         signingMethod
 	)
 
-	// TODO: Add Signing Method flag
-
 	createCmd := &cobra.Command{
 		Use:   "create",
 		Short: "create jwt",
@@ -32,7 +38,13 @@ func newCreateCommand() *cobra.Command {
     $ now=$(date +%s) exp=$(date -d "-1 month" +%s); genc jwt create --signing-key "verysecret" --claims "{\"nbf\": $now, \"iat\": $now, \"exp\": $exp, \"sub\": \"imsudonow\", \"groups\": [\"admin\", \"superadmin\"]}"
 
     # Create a jwt with no claims
-    $ genc jwt create --signing-key "verysecret"`,
+    $ genc jwt create --signing-key "verysecret"
+
+    # Create a jwt signed with a private key, using the signing method for the key type (RS256, ES256/ES384/ES512 or EdDSA)
+    $ genc jwt create --private-key rsa.key
+
+    # Create a jwt signed with an encrypted private key, using a specific signing method
+    $ genc jwt create --private-key rsa.key --passphrase-file passphrase.txt --signing-method PS256`,
 		Run: func(cmd *cobra.Command, args []string) {
 			var m map[string]interface{}
 
@@ -43,9 +55,26 @@ func newCreateCommand() *cobra.Command {
 				}
 			}
 
-			tkn := createToken(m)
+			var key interface{} = []byte(signingKey)
+
+			if privateKey != "" {
+				var err error
+
+				key, err = keys.LoadPrivateKey(privateKey, keys.Passphrase(passphraseFile))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading private key: %w", err))
+					os.Exit(1)
+				}
+			}
 
-			sig, err := tkn.SignedString([]byte(signingKey))
+			sm := method.SigningMethod
+			if sm == nil {
+				sm = defaultSigningMethod(key)
+			}
+
+			tkn := createToken(sm, m)
+
+			sig, err := tkn.SignedString(key)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, fmt.Errorf("error getting signed token: %w", err))
 				os.Exit(1)
@@ -56,18 +85,20 @@ func newCreateCommand() *cobra.Command {
 	}
 
 	createCmd.Flags().StringVar(&signingKey, "signing-key", "", "the signing key to create the jwt with")
+	createCmd.Flags().StringVar(&privateKey, "private-key", "", "the location of the private key to sign the jwt with on disk")
+	createCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
 	createCmd.Flags().StringVar(&claims, "claims", "", "claims that the jwt should be created with")
+	createCmd.Flags().Var(&method, "signing-method", "the signing method, defaults to HS256 for signing keys and is based on the key type for private keys")
 
-	if err := createCmd.MarkFlagRequired("signing-key"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'signing-key' as required: %w", err))
-	}
+	createCmd.MarkFlagsOneRequired("signing-key", "private-key")
+	createCmd.MarkFlagsMutuallyExclusive("signing-key", "private-key")
 
 	return createCmd
 }
 
-func createToken(claims map[string]interface{}) *jwt.Token {
+func createToken(method jwt.SigningMethod, claims map[string]interface{}) *jwt.Token {
 	if len(claims) == 0 {
-		return jwt.New(jwt.SigningMethodHS256)
+		return jwt.New(method)
 	}
 
 	mc := jwt.MapClaims{}
@@ -76,5 +107,27 @@ func createToken(claims map[string]interface{}) *jwt.Token {
 		mc[k] = v
 	}
 
-	return jwt.NewWithClaims(jwt.SigningMethodHS256, mc)
+	return jwt.NewWithClaims(method, mc)
+}
+
+// defaultSigningMethod returns the signing method to use for key, when one
+// hasn't been specified.
+func defaultSigningMethod(key interface{}) jwt.SigningMethod {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 384:
+			return jwt.SigningMethodES384
+		case 521:
+			return jwt.SigningMethodES512
+		}
+
+		return jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA
+	}
+
+	return jwt.SigningMethodHS256
 }
@@ -0,0 +1,63 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestCreateToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        crypto.Signer
+		wantMethod jwt.SigningMethod
+	}{
+		{name: "RSA", key: rsaKey, wantMethod: jwt.SigningMethodRS256},
+		{name: "ECDSA", key: ecKey, wantMethod: jwt.SigningMethodES384},
+		{name: "Ed25519", key: edKey, wantMethod: jwt.SigningMethodEdDSA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := defaultSigningMethod(tt.key)
+			if sm != tt.wantMethod {
+				t.Errorf("defaultSigningMethod was expected to return '%s' but returned '%s'", tt.wantMethod.Alg(), sm.Alg())
+			}
+
+			sig, err := createToken(sm, map[string]interface{}{"sub": "wibble"}).SignedString(tt.key)
+			if err != nil {
+				t.Errorf("SignedString returned an error when one wasn't expected: %+v", err)
+			}
+
+			tkn, err := jwt.Parse(sig, func(*jwt.Token) (interface{}, error) { return tt.key.Public(), nil })
+			if err != nil {
+				t.Errorf("Parse returned an error when one wasn't expected: %+v", err)
+			}
+
+			if sub, _ := tkn.Claims.GetSubject(); sub != "wibble" {
+				t.Errorf("subject was expected to be 'wibble' but was '%s'", sub)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// signingMethod implements a custom type to be used with Cobra.
+//
+// It ensures that the signing method is one that is supported by the jwt
+// library.
+
+package jwt
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type signingMethod struct {
+	jwt.SigningMethod
+}
+
+func (s *signingMethod) String() string {
+	if s.SigningMethod == nil {
+		return ""
+	}
+
+	return s.Alg()
+}
+
+func (s *signingMethod) Set(v string) error {
+	m := jwt.GetSigningMethod(v)
+	if m == nil || v == "none" {
+		return errors.New(`must be one of HS256, HS384, HS512, RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, or EdDSA`)
+	}
+
+	s.SigningMethod = m
+
+	return nil
+}
+
+func (s *signingMethod) Type() string {
+	return "[HS256,HS384,HS512,RS256,RS384,RS512,PS256,PS384,PS512,ES256,ES384,ES512,EdDSA]"
+}
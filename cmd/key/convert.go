@@ -0,0 +1,307 @@
+package key
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+	"github.com/simondrake/genc/internal/output"
+)
+
+type convertOptions struct {
+	format   convertFormat
+	encoding encoding
+	public   bool
+	comment  string
+	// passphrase is used to encrypt the output, which isn't encrypted if
+	// it's nil
+	passphrase keys.PassphraseFunc
+	// certs are included in pkcs12 output, the first must match the key
+	certs []*x509.Certificate
+}
+
+func newConvertCommand() *cobra.Command {
+	var (
+		in                string
+		passphraseFile    string
+		encrypt           bool
+		outPassphraseFile string
+		certFile          string
+		out               string
+	)
+
+	opts := convertOptions{encoding: encodingPEM}
+
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "convert a key between formats",
+		Long: `convert a private or public key between PKCS#1, PKCS#8, SEC1, PKIX, OpenSSH, JWK and PKCS#12.
+
+The input can be in any of these formats, PEM or DER encoded, and can also be a certificate when converting a public key.`,
+		Example: `
+    # Convert a private key to PKCS#1, like openssl pkey -traditional
+    $ genc key convert --in rsa.key --to pkcs1 --out pkcs1.key
+
+    # Extract the public key, DER encoded
+    $ genc key convert --in rsa.key --to pkix --encoding der --out rsa.pub.der
+
+    # Convert a private key to an encrypted PKCS#8 key
+    $ genc key convert --in rsa.key --to pkcs8 --encrypt --out encrypted.key
+
+    # Convert a public key to an OpenSSH authorized key, or a JWK
+    $ genc key convert --in rsa.pub --to openssh --comment wibble@example.com
+    $ genc key convert --in ec.key --to jwk --public
+
+    # Bundle a private key and certificate chain as PKCS#12
+    $ genc key convert --in server.key --to pkcs12 --cert chain.pem --out-passphrase-file pass.txt --out server.p12`,
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := loadKey(in, passphraseFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error loading key: %w", err))
+				os.Exit(1)
+			}
+
+			if encrypt || outPassphraseFile != "" {
+				opts.passphrase = keys.NewPassphrase(outPassphraseFile)
+			}
+
+			if certFile != "" {
+				b, err := os.ReadFile(certFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading certificate: %w", err))
+					os.Exit(1)
+				}
+
+				bundle, err := certs.Parse(b)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error parsing certificate: %w", err))
+					os.Exit(1)
+				}
+
+				opts.certs = bundle.Certificates
+			}
+
+			b, private, err := convertKey(key, opts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error converting key: %w", err))
+				os.Exit(1)
+			}
+
+			perm := os.FileMode(0o644)
+			if private {
+				perm = 0o600
+			}
+
+			if err := output.WriteFile(out, b, perm); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing key: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	convertCmd.Flags().StringVar(&in, "in", "", "the location of the key on disk")
+	convertCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the input key's passphrase on disk, prompted for if not provided")
+	convertCmd.Flags().Var(&opts.format, "to", "the format to convert the key to")
+	convertCmd.Flags().Var(&opts.encoding, "encoding", "the encoding of the output, for pkcs1, pkcs8, sec1 and pkix")
+	convertCmd.Flags().BoolVar(&opts.public, "public", false, "whether to only output the public key")
+	convertCmd.Flags().StringVar(&opts.comment, "comment", "", "the comment, for openssh keys")
+	convertCmd.Flags().BoolVar(&encrypt, "encrypt", false, "whether to encrypt the output, for pkcs8, openssh and pkcs12 (the passphrase is prompted for)")
+	convertCmd.Flags().StringVar(&outPassphraseFile, "out-passphrase-file", "", "the location of the passphrase to encrypt the output with on disk, implies --encrypt")
+	convertCmd.Flags().StringVar(&certFile, "cert", "", "the location of the certificate, and any chain, on disk, for pkcs12")
+	convertCmd.Flags().StringVar(&out, "out", "", "the location to write the key to, defaults to stdout")
+
+	if err := convertCmd.MarkFlagRequired("in"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'in' as required: %w", err))
+	}
+	if err := convertCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'to' as required: %w", err))
+	}
+
+	return convertCmd
+}
+
+// loadKey reads a private key, public key, JWK or certificate, returning the
+// private key if there is one, and the public key otherwise.
+func loadKey(path, passphraseFile string) (interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return keys.ParseJWK(b)
+	}
+
+	priv, privErr := keys.ParsePrivateKey(b, keys.Passphrase(passphraseFile))
+	if privErr == nil {
+		return priv, nil
+	}
+
+	if pub, err := keys.ParsePublicKey(b); err == nil {
+		return pub, nil
+	}
+
+	if bundle, err := certs.Parse(b); err == nil && len(bundle.Certificates) > 0 {
+		return bundle.Certificates[0].PublicKey, nil
+	}
+
+	return nil, privErr
+}
+
+// convertKey encodes key in the requested format, returning whether the
+// output contains a private key.
+func convertKey(key interface{}, opts convertOptions) ([]byte, bool, error) {
+	pub, err := keys.PublicKey(key)
+	if err != nil {
+		// key is already a public key
+		pub, key = key, nil
+	}
+
+	if opts.public || opts.format == convertFormatPKIX {
+		key = nil
+	}
+
+	if key == nil {
+		b, err := convertPublicKey(pub, opts)
+		return b, false, err
+	}
+
+	b, err := convertPrivateKey(key, opts)
+
+	return b, true, err
+}
+
+func convertPublicKey(pub crypto.PublicKey, opts convertOptions) ([]byte, error) {
+	if opts.passphrase != nil {
+		return nil, errors.New("public keys can't be encrypted")
+	}
+
+	switch opts.format {
+	case convertFormatPKIX:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		return encode(&pem.Block{Type: "PUBLIC KEY", Bytes: der}, opts.encoding), nil
+	case convertFormatPKCS1:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("pkcs1 is only supported for rsa keys, got '%T'", pub)
+		}
+
+		return encode(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}, opts.encoding), nil
+	case convertFormatOpenSSH:
+		k, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		b := bytes.TrimSuffix(ssh.MarshalAuthorizedKey(k), []byte("\n"))
+		if opts.comment != "" {
+			b = append(append(b, ' '), opts.comment...)
+		}
+
+		return append(b, '\n'), nil
+	case convertFormatJWK:
+		b, err := keys.MarshalJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(b, '\n'), nil
+	}
+
+	return nil, fmt.Errorf("%s requires a private key", opts.format)
+}
+
+func convertPrivateKey(key crypto.PrivateKey, opts convertOptions) ([]byte, error) {
+	var passphrase []byte
+
+	if opts.passphrase != nil {
+		switch opts.format {
+		case convertFormatPKCS8, convertFormatOpenSSH, convertFormatPKCS12:
+		default:
+			return nil, fmt.Errorf("encrypted output is only supported for pkcs8, openssh and pkcs12, not %s", opts.format)
+		}
+
+		var err error
+		if passphrase, err = opts.passphrase(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch opts.format {
+	case convertFormatPKCS1, convertFormatSEC1, convertFormatPKCS8:
+		if passphrase != nil {
+			der, err := keys.EncryptPKCS8(key, passphrase)
+			if err != nil {
+				return nil, err
+			}
+
+			return encode(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}, opts.encoding), nil
+		}
+
+		b, err := keys.MarshalPrivateKey(key, keys.Format(opts.format))
+		if err != nil {
+			return nil, err
+		}
+
+		return encode(b, opts.encoding), nil
+	case convertFormatOpenSSH:
+		var (
+			b   *pem.Block
+			err error
+		)
+
+		if passphrase != nil {
+			b, err = ssh.MarshalPrivateKeyWithPassphrase(key, opts.comment, passphrase)
+		} else {
+			b, err = ssh.MarshalPrivateKey(key, opts.comment)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return pem.EncodeToMemory(b), nil
+	case convertFormatJWK:
+		b, err := keys.MarshalJWK(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(b, '\n'), nil
+	case convertFormatPKCS12:
+		if len(opts.certs) == 0 {
+			return nil, errors.New("pkcs12 requires a certificate, use --cert")
+		}
+
+		if k, ok := opts.certs[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(key.(crypto.Signer).Public()) {
+			return nil, errors.New("the certificate does not match the private key")
+		}
+
+		return pkcs12.Modern.Encode(key, opts.certs[0], opts.certs[1:], string(passphrase))
+	}
+
+	return nil, fmt.Errorf("unsupported format '%s'", opts.format)
+}
+
+func encode(b *pem.Block, enc encoding) []byte {
+	if enc == encodingDER {
+		return b.Bytes
+	}
+
+	return pem.EncodeToMemory(b)
+}
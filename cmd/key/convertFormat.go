@@ -0,0 +1,85 @@
+// convertFormat implements a custom type to be used with Cobra.
+//
+// It ensures that the format a key is converted to is one of the formats
+// that genc can write.
+
+package key
+
+import (
+	"errors"
+	"strings"
+)
+
+type convertFormat string
+
+const (
+	convertFormatPKCS1   convertFormat = "pkcs1"
+	convertFormatPKCS8   convertFormat = "pkcs8"
+	convertFormatSEC1    convertFormat = "sec1"
+	convertFormatPKIX    convertFormat = "pkix"
+	convertFormatOpenSSH convertFormat = "openssh"
+	convertFormatJWK     convertFormat = "jwk"
+	convertFormatPKCS12  convertFormat = "pkcs12"
+)
+
+var convertFormats = []convertFormat{
+	convertFormatPKCS1,
+	convertFormatPKCS8,
+	convertFormatSEC1,
+	convertFormatPKIX,
+	convertFormatOpenSSH,
+	convertFormatJWK,
+	convertFormatPKCS12,
+}
+
+func (f *convertFormat) String() string {
+	return string(*f)
+}
+
+func (f *convertFormat) Set(v string) error {
+	for _, cf := range convertFormats {
+		if string(cf) == v {
+			*f = cf
+			return nil
+		}
+	}
+
+	return errors.New(`must be one of pkcs1, pkcs8, sec1, pkix, openssh, jwk, or pkcs12`)
+}
+
+func (f *convertFormat) Type() string {
+	s := make([]string, len(convertFormats))
+	for i, cf := range convertFormats {
+		s[i] = string(cf)
+	}
+
+	return "[" + strings.Join(s, ",") + "]"
+}
+
+// encoding implements a custom type to be used with Cobra.
+//
+// It ensures that the encoding is one of pem or der.
+type encoding string
+
+const (
+	encodingPEM encoding = "pem"
+	encodingDER encoding = "der"
+)
+
+func (e *encoding) String() string {
+	return string(*e)
+}
+
+func (e *encoding) Set(v string) error {
+	switch encoding(v) {
+	case encodingPEM, encodingDER:
+		*e = encoding(v)
+		return nil
+	default:
+		return errors.New(`must be one of pem, or der`)
+	}
+}
+
+func (e *encoding) Type() string {
+	return "[pem,der]"
+}
@@ -0,0 +1,142 @@
+package key
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+)
+
+func TestConvertKey(t *testing.T) {
+	key, err := keys.Generate(keys.RSA, 2048, "")
+	if err != nil {
+		t.Fatalf("Generate returned an error when one wasn't expected: %+v", err)
+	}
+
+	passphrase := func() ([]byte, error) { return []byte("genc"), nil }
+
+	chain := certChain(t, key)
+
+	tests := []struct {
+		name    string
+		opts    convertOptions
+		private bool
+	}{
+		{name: "PKCS1", opts: convertOptions{format: convertFormatPKCS1, encoding: encodingPEM}, private: true},
+		{name: "PKCS8 DER", opts: convertOptions{format: convertFormatPKCS8, encoding: encodingDER}, private: true},
+		{name: "Encrypted PKCS8", opts: convertOptions{format: convertFormatPKCS8, encoding: encodingPEM, passphrase: passphrase}, private: true},
+		{name: "OpenSSH", opts: convertOptions{format: convertFormatOpenSSH, passphrase: passphrase}, private: true},
+		{name: "JWK", opts: convertOptions{format: convertFormatJWK}, private: true},
+		{name: "PKCS12 With CA", opts: convertOptions{format: convertFormatPKCS12, passphrase: passphrase, certs: chain}, private: true},
+		{name: "PKCS12 With CA Without Passphrase", opts: convertOptions{format: convertFormatPKCS12, certs: chain}, private: true},
+		{name: "PKIX", opts: convertOptions{format: convertFormatPKIX, encoding: encodingPEM}},
+		{name: "PKCS1 Public", opts: convertOptions{format: convertFormatPKCS1, encoding: encodingPEM, public: true}},
+		{name: "OpenSSH Public", opts: convertOptions{format: convertFormatOpenSSH, public: true}},
+		{name: "JWK Public", opts: convertOptions{format: convertFormatJWK, public: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, private, err := convertKey(key, tt.opts)
+			if err != nil {
+				t.Fatalf("convertKey returned an error when one wasn't expected: %+v", err)
+			}
+
+			if private != tt.private {
+				t.Errorf("expected private to be %v", tt.private)
+			}
+
+			var parsed interface{}
+
+			switch {
+			case tt.opts.format == convertFormatJWK:
+				parsed, err = keys.ParseJWK(b)
+			case tt.private:
+				parsed, err = keys.ParsePrivateKey(b, passphrase)
+			default:
+				parsed, err = keys.ParsePublicKey(b)
+			}
+
+			if err != nil {
+				t.Fatalf("failed to parse the converted key: %+v", err)
+			}
+
+			pub, err := keys.PublicKey(parsed)
+			if err != nil {
+				pub = parsed
+			}
+
+			if k, ok := pub.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(key.Public()) {
+				t.Errorf("converted key does not match the original key")
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, opts := range []convertOptions{
+			{format: convertFormatSEC1},
+			{format: convertFormatPKCS1, passphrase: passphrase},
+			{format: convertFormatPKCS12},
+		} {
+			if _, _, err := convertKey(key, opts); err == nil {
+				t.Errorf("expected an error converting to %s", opts.format)
+			}
+		}
+
+		if _, _, err := convertKey(key.Public(), convertOptions{format: convertFormatPKCS8}); err == nil {
+			t.Errorf("expected an error converting a public key to pkcs8")
+		}
+	})
+}
+
+// certChain returns a certificate for key, and the CA certificate that issued
+// it.
+func certChain(t *testing.T, key crypto.Signer) []*x509.Certificate {
+	caKey, err := keys.Generate(keys.RSA, 2048, "")
+	if err != nil {
+		t.Fatalf("Generate returned an error when one wasn't expected: %+v", err)
+	}
+
+	caTmpl, err := certs.NewTemplate(pkix.Name{CommonName: "genc test CA"}, time.Now().AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	caTmpl.IsCA = true
+	caTmpl.BasicConstraintsValid = true
+	caTmpl.KeyUsage = x509.KeyUsageCertSign
+
+	leafTmpl, err := certs.NewTemplate(pkix.Name{CommonName: "genc test"}, time.Now().AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	var chain []*x509.Certificate
+
+	for _, c := range []struct {
+		tmpl *x509.Certificate
+		pub  crypto.PublicKey
+	}{
+		{leafTmpl, key.Public()},
+		{caTmpl, caKey.Public()},
+	} {
+		der, err := x509.CreateCertificate(rand.Reader, c.tmpl, caTmpl, c.pub, caKey)
+		if err != nil {
+			t.Fatalf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		chain = append(chain, cert)
+	}
+
+	return chain
+}
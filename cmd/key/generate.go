@@ -0,0 +1,84 @@
+package key
+
+import (
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/keys"
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newGenerateCommand() *cobra.Command {
+	var (
+		bits      int
+		curve     string
+		out       string
+		publicOut string
+	)
+
+	algorithm := keys.RSA
+	format := keys.PKCS8
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "generate a new private key",
+		Long:  "generate a new RSA, ECDSA or Ed25519 private key, PEM encoded as PKCS#1, PKCS#8 or SEC1",
+		Example: `
+    # Generate a 2048 bit RSA key, in PKCS#8 format
+    $ genc key generate --out rsa.key
+
+    # Generate a 4096 bit RSA key in PKCS#1 format, and save the public key
+    $ genc key generate --bits 4096 --format pkcs1 --out rsa.key --public-out rsa.pub
+
+    # Generate a P-384 ECDSA key, in SEC1 format
+    $ genc key generate --algorithm ecdsa --curve P-384 --format sec1 --out ec.key
+
+    # Generate an Ed25519 key
+    $ genc key generate --algorithm ed25519`,
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := keys.Generate(algorithm, bits, curve)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error generating key: %w", err))
+				os.Exit(1)
+			}
+
+			b, err := keys.MarshalPrivateKey(key, format)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error marshalling private key: %w", err))
+				os.Exit(1)
+			}
+
+			if err := output.WriteFile(out, pem.EncodeToMemory(b), 0o600); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing private key: %w", err))
+				os.Exit(1)
+			}
+
+			if publicOut == "" {
+				return
+			}
+
+			pb, err := keys.MarshalPublicKey(key.Public())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error marshalling public key: %w", err))
+				os.Exit(1)
+			}
+
+			if err := os.WriteFile(publicOut, pem.EncodeToMemory(pb), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing public key: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	generateCmd.Flags().Var(&algorithm, "algorithm", "the key algorithm")
+	generateCmd.Flags().IntVar(&bits, "bits", 2048, "the size of the key, for rsa keys")
+	generateCmd.Flags().StringVar(&curve, "curve", "P-256", "the curve, for ecdsa keys (P-224, P-256, P-384 or P-521)")
+	generateCmd.Flags().Var(&format, "format", "the format of the private key")
+	generateCmd.Flags().StringVar(&out, "out", "", "the location to write the private key to, defaults to stdout")
+	generateCmd.Flags().StringVar(&publicOut, "public-out", "", "the location to write the public key to")
+
+	return generateCmd
+}
@@ -0,0 +1,16 @@
+package key
+
+import "github.com/spf13/cobra"
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "private key related commands",
+	}
+
+	cmd.AddCommand(newGenerateCommand())
+	cmd.AddCommand(newMatchCommand())
+	cmd.AddCommand(newConvertCommand())
+
+	return cmd
+}
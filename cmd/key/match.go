@@ -0,0 +1,172 @@
+package key
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+)
+
+type matchItem struct {
+	label string
+	path  string
+	pub   crypto.PublicKey
+}
+
+func newMatchCommand() *cobra.Command {
+	var (
+		privateKey     string
+		passphraseFile string
+		publicKey      string
+		csrFile        string
+		certFile       string
+	)
+
+	matchCmd := &cobra.Command{
+		Use:   "match",
+		Short: "check that keys, CSRs and certificates share the same public key",
+		Long:  "check that a private key, public key, certificate signing request and certificate all share the same public key. At least two of them must be provided",
+		Example: `
+    # Check that a private key belongs to a certificate, before using them with genc pkcs7 decrypt
+    $ genc key match --private-key rsa.key --cert domain.crt
+
+    # Check a private key, CSR and certificate
+    $ genc key match --private-key rsa.key --csr domain.csr --cert domain.crt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var items []matchItem
+
+			if privateKey != "" {
+				pub, err := loadPrivateKeyPublic(privateKey, passphraseFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading private key: %w", err))
+					os.Exit(1)
+				}
+
+				items = append(items, matchItem{label: "Private Key", path: privateKey, pub: pub})
+			}
+
+			if publicKey != "" {
+				pub, err := keys.LoadPublicKey(publicKey)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading public key: %w", err))
+					os.Exit(1)
+				}
+
+				items = append(items, matchItem{label: "Public Key", path: publicKey, pub: pub})
+			}
+
+			if csrFile != "" {
+				b, err := loadBundle(csrFile)
+				if err != nil || len(b.Requests) == 0 {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading csr: %w", orNotFound(err, "csr")))
+					os.Exit(1)
+				}
+
+				items = append(items, matchItem{label: "CSR", path: csrFile, pub: b.Requests[0].PublicKey})
+			}
+
+			if certFile != "" {
+				b, err := loadBundle(certFile)
+				if err != nil || len(b.Certificates) == 0 {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading certificate: %w", orNotFound(err, "certificate")))
+					os.Exit(1)
+				}
+
+				items = append(items, matchItem{label: "Certificate", path: certFile, pub: b.Certificates[0].PublicKey})
+			}
+
+			if len(items) < 2 {
+				fmt.Fprintln(os.Stderr, errors.New("at least two of --private-key, --public-key, --csr and --cert are required"))
+				os.Exit(1)
+			}
+
+			ok, err := writeMatch(os.Stdout, items)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error checking keys: %w", err))
+				os.Exit(1)
+			}
+
+			if !ok {
+				os.Exit(1)
+			}
+		},
+	}
+
+	matchCmd.Flags().StringVar(&privateKey, "private-key", "", "the location of the private key on disk")
+	matchCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
+	matchCmd.Flags().StringVar(&publicKey, "public-key", "", "the location of the public key on disk")
+	matchCmd.Flags().StringVar(&csrFile, "csr", "", "the location of the certificate signing request on disk")
+	matchCmd.Flags().StringVar(&certFile, "cert", "", "the location of the certificate on disk")
+
+	return matchCmd
+}
+
+func loadPrivateKeyPublic(path, passphraseFile string) (crypto.PublicKey, error) {
+	k, err := keys.LoadPrivateKey(path, keys.Passphrase(passphraseFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return keys.PublicKey(k)
+}
+
+func loadBundle(path string) (*certs.Bundle, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return certs.Parse(b)
+}
+
+func orNotFound(err error, what string) error {
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("no %s was found", what)
+}
+
+// writeMatch writes the SPKI pin of each item, and whether they all match.
+func writeMatch(w io.Writer, items []matchItem) (bool, error) {
+	var (
+		sb    strings.Builder
+		first []byte
+		ok    = true
+	)
+
+	for _, item := range items {
+		der, err := x509.MarshalPKIXPublicKey(item.pub)
+		if err != nil {
+			return false, fmt.Errorf("error marshalling %s public key: %w", strings.ToLower(item.label), err)
+		}
+
+		if first == nil {
+			first = der
+		} else if !bytes.Equal(first, der) {
+			ok = false
+		}
+
+		fmt.Fprintf(&sb, "%-24s%s (%s)\n", item.label+":", certs.SPKIPin(der), item.path)
+	}
+
+	result := "match"
+	if !ok {
+		result = "MISMATCH"
+	}
+
+	fmt.Fprintf(&sb, "%-24s%s\n", "Result:", result)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return ok, err
+}
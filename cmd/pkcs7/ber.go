@@ -0,0 +1,148 @@
+package pkcs7
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ber2der re-encodes a BER encoded ASN.1 structure using definite lengths,
+// so that it can be consumed by encoding/asn1.
+//
+// Tools such as OpenSSL produce indefinite-length encodings when streaming
+// output, which encoding/asn1 refuses to parse.
+func ber2der(ber []byte) ([]byte, error) {
+	if len(ber) == 0 {
+		return nil, errors.New("input is empty")
+	}
+
+	var out bytes.Buffer
+
+	if _, err := transcodeBER(&out, ber); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// transcodeBER writes the first object in ber to out, returning the number
+// of bytes of ber that were consumed.
+func transcodeBER(out *bytes.Buffer, ber []byte) (int, error) {
+	if len(ber) < 2 {
+		return 0, errors.New("ber: truncated object")
+	}
+
+	// Identifier octets - high tag numbers continue while the top bit is set
+	offset := 1
+	if ber[0]&0x1f == 0x1f {
+		for {
+			if offset >= len(ber) {
+				return 0, errors.New("ber: truncated tag")
+			}
+
+			offset++
+
+			if ber[offset-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+
+	tag := ber[:offset]
+	compound := ber[0]&0x20 != 0
+
+	if offset >= len(ber) {
+		return 0, errors.New("ber: truncated length")
+	}
+
+	l := int(ber[offset])
+	offset++
+
+	if l == 0x80 {
+		if !compound {
+			return 0, errors.New("ber: indefinite length on primitive object")
+		}
+
+		var inner bytes.Buffer
+
+		for {
+			if offset+2 > len(ber) {
+				return 0, errors.New("ber: missing end-of-contents")
+			}
+
+			if ber[offset] == 0 && ber[offset+1] == 0 {
+				offset += 2
+				break
+			}
+
+			n, err := transcodeBER(&inner, ber[offset:])
+			if err != nil {
+				return 0, err
+			}
+
+			offset += n
+		}
+
+		writeDER(out, tag, inner.Bytes())
+
+		return offset, nil
+	}
+
+	if l > 0x80 {
+		n := l & 0x7f
+		if n > 4 || offset+n > len(ber) {
+			return 0, errors.New("ber: unsupported length")
+		}
+
+		l = 0
+		for _, b := range ber[offset : offset+n] {
+			l = l<<8 | int(b)
+		}
+
+		offset += n
+	}
+
+	if l < 0 || offset+l > len(ber) {
+		return 0, errors.New("ber: length exceeds input")
+	}
+
+	content := ber[offset : offset+l]
+
+	if !compound {
+		writeDER(out, tag, content)
+		return offset + l, nil
+	}
+
+	var inner bytes.Buffer
+
+	for i := 0; i < len(content); {
+		n, err := transcodeBER(&inner, content[i:])
+		if err != nil {
+			return 0, err
+		}
+
+		i += n
+	}
+
+	writeDER(out, tag, inner.Bytes())
+
+	return offset + l, nil
+}
+
+func writeDER(out *bytes.Buffer, tag []byte, content []byte) {
+	out.Write(tag)
+
+	switch l := len(content); {
+	case l < 0x80:
+		out.WriteByte(byte(l))
+	default:
+		var lb []byte
+		for ; l > 0; l >>= 8 {
+			lb = append([]byte{byte(l)}, lb...)
+		}
+
+		out.WriteByte(0x80 | byte(len(lb)))
+		out.Write(lb)
+	}
+
+	out.Write(content)
+}
@@ -0,0 +1,53 @@
+// contentCipher implements a custom type to be used with Cobra.
+//
+// It ensures that the content encryption algorithm is one of the algorithms
+// that can be used to create a new envelope.
+
+package pkcs7
+
+import (
+	"errors"
+	"strings"
+)
+
+type contentCipher string
+
+const (
+	contentCipherAES128CBC contentCipher = "aes128-cbc"
+	contentCipherAES256CBC contentCipher = "aes256-cbc"
+	contentCipherAES128GCM contentCipher = "aes128-gcm"
+	contentCipherAES256GCM contentCipher = "aes256-gcm"
+	contentCipherDESEDE3   contentCipher = "des-ede3"
+)
+
+var contentCiphers = []contentCipher{
+	contentCipherAES128CBC,
+	contentCipherAES256CBC,
+	contentCipherAES128GCM,
+	contentCipherAES256GCM,
+	contentCipherDESEDE3,
+}
+
+func (c *contentCipher) String() string {
+	return string(*c)
+}
+
+func (c *contentCipher) Set(v string) error {
+	for _, cc := range contentCiphers {
+		if string(cc) == v {
+			*c = cc
+			return nil
+		}
+	}
+
+	return errors.New(`must be one of aes128-cbc, aes256-cbc, aes128-gcm, aes256-gcm, or des-ede3`)
+}
+
+func (c *contentCipher) Type() string {
+	s := make([]string, len(contentCiphers))
+	for i, cc := range contentCiphers {
+		s[i] = string(cc)
+	}
+
+	return "[" + strings.Join(s, ",") + "]"
+}
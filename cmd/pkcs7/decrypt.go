@@ -1,7 +1,6 @@
 package pkcs7
 
 import (
-	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
@@ -9,21 +8,30 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/fullsailor/pkcs7"
 	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/keys"
 )
 
 func newDecryptCommand() *cobra.Command {
 	var (
-		encString  string
-		publicKey  string
-		privateKey string
-		b64        bool
+		encString      string
+		publicKey      string
+		privateKey     string
+		passphraseFile string
+		b64            bool
 	)
 
 	decryptCmd := &cobra.Command{
 		Use:   "decrypt",
 		Short: "decrypt pkcs7 secret",
+		Long:  "decrypt pkcs7 secret. If the public key is not provided, the recipient is located using the private key",
+		Example: `
+    # Decrypt, locating the recipient with the certificate
+    $ genc pkcs7 decrypt --private-key rsa.key --public-key domain.crt --string "MIIBvQYJKoZIhvcNAQcDoIIBrjCC..."
+
+    # Decrypt, locating the recipient with the private key
+    $ genc pkcs7 decrypt --private-key rsa.key --string "MIIBvQYJKoZIhvcNAQcDoIIBrjCC..."`,
 		Run: func(cmd *cobra.Command, args []string) {
 			privKey, err := os.ReadFile(privateKey)
 			if err != nil {
@@ -31,13 +39,17 @@ func newDecryptCommand() *cobra.Command {
 				os.Exit(1)
 			}
 
-			pubKey, err := os.ReadFile(publicKey)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, fmt.Errorf("error reading public key: %w", err))
-				os.Exit(1)
+			var pubKey []byte
+
+			if publicKey != "" {
+				pubKey, err = os.ReadFile(publicKey)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading public key: %w", err))
+					os.Exit(1)
+				}
 			}
 
-			b, err := decryptPKCS7(privKey, pubKey, b64, encString)
+			b, err := decryptPKCS7(privKey, pubKey, keys.Passphrase(passphraseFile), b64, encString)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, fmt.Errorf("error decrypting string: %w", err))
 				os.Exit(1)
@@ -48,16 +60,14 @@ func newDecryptCommand() *cobra.Command {
 	}
 
 	decryptCmd.Flags().StringVar(&encString, "string", "", "the encrypted string")
-	decryptCmd.Flags().StringVar(&publicKey, "public-key", "", "the location of the public key on disk")
+	decryptCmd.Flags().StringVar(&publicKey, "public-key", "", "the location of the public key on disk (optional)")
 	decryptCmd.Flags().StringVar(&privateKey, "private-key", "", "the location of the private key on disk")
+	decryptCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
 	decryptCmd.Flags().BoolVar(&b64, "base64", true, "whether the encrypted string is base64 encoded")
 
 	if err := decryptCmd.MarkFlagRequired("string"); err != nil {
 		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'string' as required: %w", err))
 	}
-	if err := decryptCmd.MarkFlagRequired("public-key"); err != nil {
-		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'public-key' as required: %w", err))
-	}
 	if err := decryptCmd.MarkFlagRequired("private-key"); err != nil {
 		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'private-key' as required: %w", err))
 	}
@@ -65,7 +75,7 @@ func newDecryptCommand() *cobra.Command {
 	return decryptCmd
 }
 
-func decryptPKCS7(privKey []byte, pubKey []byte, b64 bool, enc string) ([]byte, error) {
+func decryptPKCS7(privKey []byte, pubKey []byte, passphrase keys.PassphraseFunc, b64 bool, enc string) ([]byte, error) {
 	p7b := []byte(enc)
 	if b64 {
 		var err error
@@ -75,41 +85,29 @@ func decryptPKCS7(privKey []byte, pubKey []byte, b64 bool, enc string) ([]byte,
 		}
 	}
 
-	p7, err := pkcs7.Parse(p7b)
+	ed, err := parseEnvelope(p7b)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing encrypted string: %w", err)
 	}
 
-	pemPriv, _ := pem.Decode(privKey)
-	if pemPriv == nil {
-		return nil, errors.New("unable to decode private key")
-	}
-
-	pemPub, _ := pem.Decode(pubKey)
-	if pemPub == nil {
-		return nil, errors.New("unable to decode public key")
-	}
-
-	cpk, err := parsePrivateKey(pemPriv)
+	cpk, err := keys.ParsePrivateKey(privKey, passphrase)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing private key: %w", err)
 	}
 
-	x509Pub, err := x509.ParseCertificate(pemPub.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing public key: %w", err)
-	}
+	var x509Pub *x509.Certificate
 
-	return p7.Decrypt(x509Pub, cpk)
-}
+	if len(pubKey) > 0 {
+		pemPub, _ := pem.Decode(pubKey)
+		if pemPub == nil {
+			return nil, errors.New("unable to decode public key")
+		}
 
-func parsePrivateKey(b *pem.Block) (crypto.PrivateKey, error) {
-	switch b.Type {
-	case "RSA PRIVATE KEY":
-		return x509.ParsePKCS1PrivateKey(b.Bytes)
-	case "PRIVATE KEY":
-		return x509.ParsePKCS8PrivateKey(b.Bytes)
+		x509Pub, err = x509.ParseCertificate(pemPub.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing public key: %w", err)
+		}
 	}
 
-	return nil, fmt.Errorf("unsupported private key type '%s'", b.Type)
+	return ed.decrypt(x509Pub, cpk)
 }
@@ -7,11 +7,14 @@ import (
 	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/pem"
-	"math/big"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fullsailor/pkcs7"
+
+	"github.com/simondrake/genc/internal/certs"
 )
 
 func TestDecryptPKCS7(t *testing.T) {
@@ -52,6 +55,7 @@ func TestDecryptPKCS7(t *testing.T) {
 		out, err := decryptPKCS7(
 			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
 			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			nil,
 			true,
 			base64.StdEncoding.EncodeToString(enc),
 		)
@@ -103,6 +107,60 @@ func TestDecryptPKCS7(t *testing.T) {
 		out, err := decryptPKCS7(
 			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8pk}),
 			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			nil,
+			true,
+			base64.StdEncoding.EncodeToString(enc),
+		)
+		if err != nil {
+			t.Errorf("decryptPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		if string(out) != plaintext {
+			t.Errorf("result of decryptPKCS7 was expected to be '%s' but was '%s'", plaintext, string(out))
+		}
+	})
+	t.Run("AES128GCM", func(t *testing.T) {
+		// Envelopes created by older versions of genc used github.com/fullsailor/pkcs7,
+		// which wraps the AES-GCM parameters in a second SEQUENCE, tags the nonce
+		// [4], and appends the authentication tag to the EnvelopedData ciphertext
+		pkcs7.ContentEncryptionAlgorithm = pkcs7.EncryptionAlgorithmAES128GCM
+		defer func() { pkcs7.ContentEncryptionAlgorithm = pkcs7.EncryptionAlgorithmDESCBC }()
+
+		// Generate new Private Key
+		privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Generate a new X509 Certificate Template
+		tmpl, err := certTemplate()
+		if err != nil {
+			t.Errorf("certTemplate returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Create Certificate DER
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &privateKey.PublicKey, privateKey)
+		if err != nil {
+			t.Errorf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Convert Public Key to X509
+		x509PublicCert, err := x509.ParseCertificates(der)
+		if err != nil {
+			t.Errorf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Encrypt our plaintext value
+		enc, err := pkcs7.Encrypt([]byte(plaintext), x509PublicCert)
+		if err != nil {
+			t.Errorf("Encrypt returned an error when one wasn't expected: %+v", err)
+		}
+
+		// The actual test
+		out, err := decryptPKCS7(
+			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			nil,
 			true,
 			base64.StdEncoding.EncodeToString(enc),
 		)
@@ -114,22 +172,91 @@ func TestDecryptPKCS7(t *testing.T) {
 			t.Errorf("result of decryptPKCS7 was expected to be '%s' but was '%s'", plaintext, string(out))
 		}
 	})
+	t.Run("WithoutPublicKey", func(t *testing.T) {
+		// Generate new Private Key
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Generate a new X509 Certificate Template
+		tmpl, err := certTemplate()
+		if err != nil {
+			t.Errorf("certTemplate returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Create Certificate DER
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &privateKey.PublicKey, privateKey)
+		if err != nil {
+			t.Errorf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Encrypt our plaintext value
+		enc, err := encryptPKCS7(plaintext, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), contentCipherAES256CBC)
+		if err != nil {
+			t.Errorf("encryptPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		privKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+		// The actual test
+		out, err := decryptPKCS7(privKey, nil, nil, true, base64.StdEncoding.EncodeToString(enc))
+		if err != nil {
+			t.Errorf("decryptPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		if string(out) != plaintext {
+			t.Errorf("result of decryptPKCS7 was expected to be '%s' but was '%s'", plaintext, string(out))
+		}
+
+		// A different private key should report the recipients of the envelope
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+		}
+
+		_, err = decryptPKCS7(
+			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(otherKey)}),
+			nil,
+			nil,
+			true,
+			base64.StdEncoding.EncodeToString(enc),
+		)
+		if err == nil {
+			t.Fatal("decryptPKCS7 was expected to return an error but didn't")
+		}
+
+		want := "serial=" + tmpl.SerialNumber.Text(16)
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error was expected to contain '%s' but was '%s'", want, err)
+		}
+
+		// The failed attempt to decrypt for the recipient should be kept
+		if errors.Unwrap(err) == nil || !strings.Contains(err.Error(), "decrypting for the issuer and serial number recipients failed") {
+			t.Errorf("error was expected to wrap the decryption error but was '%s'", err)
+		}
+
+		// A certificate that doesn't match the private key should be reported
+		_, err = decryptPKCS7(
+			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(otherKey)}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			nil,
+			true,
+			base64.StdEncoding.EncodeToString(enc),
+		)
+		if err == nil || !strings.Contains(err.Error(), "does not match") {
+			t.Errorf("decryptPKCS7 was expected to return a mismatch error but returned '%v'", err)
+		}
+	})
 }
 
 func certTemplate() (*x509.Certificate, error) {
-	limit := new(big.Int).Lsh(big.NewInt(1), 128)
-
-	sn, err := rand.Int(rand.Reader, limit)
+	tmpl, err := certs.NewTemplate(pkix.Name{Organization: []string{"Wibble Wobble, Inc."}}, time.Now().AddDate(1, 0, 0)) // valid for a year
 	if err != nil {
 		return nil, err
 	}
 
-	return &x509.Certificate{
-		SerialNumber:          sn,
-		Subject:               pkix.Name{Organization: []string{"Wibble Wobble, Inc."}},
-		SignatureAlgorithm:    x509.SHA256WithRSA,
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(1, 0, 0), // valid for a year
-		BasicConstraintsValid: true,
-	}, nil
+	tmpl.SignatureAlgorithm = x509.SHA256WithRSA
+
+	return tmpl, nil
 }
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/fullsailor/pkcs7"
 	"github.com/spf13/cobra"
 )
 
@@ -19,9 +18,17 @@ func newEncryptCommand() *cobra.Command {
 		b64       bool
 	)
 
+	cc := contentCipherAES256CBC
+
 	encryptCmd := &cobra.Command{
 		Use:   "encrypt",
 		Short: "encrypt plaintext with pkcs7",
+		Example: `
+    # Encrypt using the default content cipher (aes256-cbc)
+    $ genc pkcs7 encrypt --public-key domain.crt --string "test"
+
+    # Encrypt using AES-128-GCM
+    $ genc pkcs7 encrypt --public-key domain.crt --string "test" --content-cipher aes128-gcm`,
 		Run: func(cmd *cobra.Command, args []string) {
 			pk, err := os.ReadFile(publicKey)
 			if err != nil {
@@ -29,7 +36,7 @@ func newEncryptCommand() *cobra.Command {
 				os.Exit(1)
 			}
 
-			b, err := encryptPKCS7(str, pk)
+			b, err := encryptPKCS7(str, pk, cc)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, fmt.Errorf("error encrypting string: %w", err))
 				os.Exit(1)
@@ -46,6 +53,7 @@ func newEncryptCommand() *cobra.Command {
 	encryptCmd.Flags().StringVar(&str, "string", "", "the string to encrypt")
 	encryptCmd.Flags().StringVar(&publicKey, "public-key", "", "the location of the public key on disk")
 	encryptCmd.Flags().BoolVar(&b64, "base64", true, "whether the string should be base64 encoded after encryption")
+	encryptCmd.Flags().Var(&cc, "content-cipher", "the algorithm used to encrypt the content")
 
 	if err := encryptCmd.MarkFlagRequired("string"); err != nil {
 		fmt.Fprintln(os.Stderr, fmt.Errorf("internal error marking flag 'string' as required: %w", err))
@@ -57,7 +65,7 @@ func newEncryptCommand() *cobra.Command {
 	return encryptCmd
 }
 
-func encryptPKCS7(str string, pubKey []byte) ([]byte, error) {
+func encryptPKCS7(str string, pubKey []byte, cc contentCipher) ([]byte, error) {
 	pemPub, _ := pem.Decode(pubKey)
 	if pemPub == nil {
 		return nil, errors.New("unable to decode public key")
@@ -68,5 +76,5 @@ func encryptPKCS7(str string, pubKey []byte) ([]byte, error) {
 		return nil, fmt.Errorf("error parsing certificates: %w", err)
 	}
 
-	return pkcs7.Encrypt([]byte(str), certs)
+	return encryptEnvelope([]byte(str), certs, cc)
 }
@@ -31,28 +31,54 @@ func TestEncryptPKCS7(t *testing.T) {
 		t.Errorf("CreateCertificate returned an error when one wasn't expected: %+v", err)
 	}
 
-	out, err := encryptPKCS7(plaintext, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
-	if err != nil {
-		t.Errorf("encryptPKCS7 returned an error when one wasn't expected: %+v", err)
-	}
-
-	// Now make sure the output can be decrypted
-	p7, err := pkcs7.Parse(out)
-	if err != nil {
-		t.Errorf("Parse returned an error when one wasn't expected: %+v", err)
-	}
-
 	x509PubCert, err := x509.ParseCertificate(der)
 	if err != nil {
 		t.Errorf("ParseCertificate returned an error when one wasn't expected: %+v", err)
 	}
 
-	dec, err := p7.Decrypt(x509PubCert, privateKey)
-	if err != nil {
-		t.Errorf("Decrypt returned an error when one wasn't expected: %+v", err)
-	}
+	for _, cc := range contentCiphers {
+		t.Run(string(cc), func(t *testing.T) {
+			out, err := encryptPKCS7(plaintext, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cc)
+			if err != nil {
+				t.Errorf("encryptPKCS7 returned an error when one wasn't expected: %+v", err)
+			}
+
+			// Now make sure the output can be decrypted
+			ed, err := parseEnvelope(out)
+			if err != nil {
+				t.Errorf("parseEnvelope returned an error when one wasn't expected: %+v", err)
+			}
 
-	if string(dec) != plaintext {
-		t.Errorf("result of Decrypt was expected to be '%s' but was '%s'", plaintext, string(out))
+			dec, err := ed.decrypt(x509PubCert, privateKey)
+			if err != nil {
+				t.Errorf("decrypt returned an error when one wasn't expected: %+v", err)
+			}
+
+			if string(dec) != plaintext {
+				t.Errorf("result of decrypt was expected to be '%s' but was '%s'", plaintext, string(dec))
+			}
+		})
 	}
+
+	t.Run("Interoperability", func(t *testing.T) {
+		out, err := encryptPKCS7(plaintext, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), contentCipherAES256CBC)
+		if err != nil {
+			t.Errorf("encryptPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		// Make sure another implementation can decrypt the output
+		p7, err := pkcs7.Parse(out)
+		if err != nil {
+			t.Errorf("Parse returned an error when one wasn't expected: %+v", err)
+		}
+
+		dec, err := p7.Decrypt(x509PubCert, privateKey)
+		if err != nil {
+			t.Errorf("Decrypt returned an error when one wasn't expected: %+v", err)
+		}
+
+		if string(dec) != plaintext {
+			t.Errorf("result of Decrypt was expected to be '%s' but was '%s'", plaintext, string(dec))
+		}
+	})
 }
@@ -0,0 +1,477 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAuthEnveloped = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// cipherSpec describes a content encryption algorithm that can appear in an
+// envelope. Only those with a name can be used to create new envelopes, the
+// others are kept so that existing envelopes remain readable.
+type cipherSpec struct {
+	name     contentCipher
+	oid      asn1.ObjectIdentifier
+	keySize  int
+	gcm      bool
+	newBlock func(key []byte) (cipher.Block, error)
+}
+
+var cipherSpecs = []cipherSpec{
+	{name: contentCipherAES128CBC, oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}, keySize: 16, newBlock: aes.NewCipher},
+	{oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}, keySize: 24, newBlock: aes.NewCipher},
+	{name: contentCipherAES256CBC, oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, keySize: 32, newBlock: aes.NewCipher},
+	{name: contentCipherAES128GCM, oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}, keySize: 16, gcm: true, newBlock: aes.NewCipher},
+	{oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}, keySize: 24, gcm: true, newBlock: aes.NewCipher},
+	{name: contentCipherAES256GCM, oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}, keySize: 32, gcm: true, newBlock: aes.NewCipher},
+	{name: contentCipherDESEDE3, oid: asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}, keySize: 24, newBlock: des.NewTripleDESCipher},
+	{oid: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 7}, keySize: 8, newBlock: des.NewCipher},
+}
+
+func cipherSpecByName(name contentCipher) (cipherSpec, bool) {
+	for _, cs := range cipherSpecs {
+		if cs.name != "" && cs.name == name {
+			return cs, true
+		}
+	}
+
+	return cipherSpec{}, false
+}
+
+func cipherSpecByOID(oid asn1.ObjectIdentifier) (cipherSpec, bool) {
+	for _, cs := range cipherSpecs {
+		if cs.oid.Equal(oid) {
+			return cs, true
+		}
+	}
+
+	return cipherSpec{}, false
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type authEnvelopedData struct {
+	Version                  int
+	OriginatorInfo           asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos           []asn1.RawValue `asn1:"set"`
+	AuthEncryptedContentInfo encryptedContentInfo
+	AuthAttrs                asn1.RawValue `asn1:"optional,tag:1"`
+	MAC                      []byte
+	UnauthAttrs              asn1.RawValue `asn1:"optional,tag:2"`
+}
+
+// envelope is the common representation of EnvelopedData and
+// AuthEnvelopedData, holding what is needed to decrypt either.
+type envelope struct {
+	contentType          asn1.ObjectIdentifier
+	version              int
+	recipientInfos       []asn1.RawValue
+	encryptedContentInfo encryptedContentInfo
+	authAttrs            asn1.RawValue
+	mac                  []byte
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// keyTransRecipientInfo is the only RecipientInfo choice that is supported.
+// The RID is either an IssuerAndSerialNumber (version 0) or a
+// [0] SubjectKeyIdentifier (version 2).
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// gcmParameters are the AES-GCM parameters defined in RFC 5084.
+type gcmParameters struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+// legacyGCMParameters are the AES-GCM parameters written by
+// github.com/fullsailor/pkcs7, which wraps them in a second SEQUENCE and
+// encodes the nonce with a context specific tag, [4]. It stores the content
+// in EnvelopedData, with the authentication tag appended to the ciphertext.
+type legacyGCMParameters struct {
+	Nonce  []byte `asn1:"tag:4"`
+	ICVLen int
+}
+
+// encryptEnvelope creates a DER encoded EnvelopedData structure, encrypting
+// content with cc and the content encryption key for each recipient.
+//
+// AES-GCM content is stored in an AuthEnvelopedData structure instead, as
+// described in RFC 5083 and RFC 5084.
+func encryptEnvelope(content []byte, recipients []*x509.Certificate, cc contentCipher) ([]byte, error) {
+	spec, ok := cipherSpecByName(cc)
+	if !ok {
+		return nil, fmt.Errorf("unsupported content cipher '%s'", cc)
+	}
+
+	key := make([]byte, spec.keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("error generating content encryption key: %w", err)
+	}
+
+	block, err := spec.newBlock(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new cipher: %w", err)
+	}
+
+	var (
+		ciphertext []byte
+		mac        []byte
+		params     []byte
+	)
+
+	if spec.gcm {
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("error creating new gcm: %w", err)
+		}
+
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("error creating nonce: %w", err)
+		}
+
+		sealed := gcm.Seal(nil, nonce, content, nil)
+		ciphertext, mac = sealed[:len(content)], sealed[len(content):]
+
+		params, err = asn1.Marshal(gcmParameters{Nonce: nonce, ICVLen: gcm.Overhead()})
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling gcm parameters: %w", err)
+		}
+	} else {
+		iv := make([]byte, block.BlockSize())
+		if _, err := rand.Read(iv); err != nil {
+			return nil, fmt.Errorf("error creating iv: %w", err)
+		}
+
+		padded := pad(content, block.BlockSize())
+		ciphertext = make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+		params, err = asn1.Marshal(iv)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling iv: %w", err)
+		}
+	}
+
+	ris := make([]asn1.RawValue, len(recipients))
+
+	for i, cert := range recipients {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("recipient '%s' does not have an RSA public key", cert.Subject)
+		}
+
+		ek, err := rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+		if err != nil {
+			return nil, fmt.Errorf("error encrypting key for recipient '%s': %w", cert.Subject, err)
+		}
+
+		rid, err := asn1.Marshal(issuerAndSerial{
+			Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+			SerialNumber: cert.SerialNumber,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling recipient identifier: %w", err)
+		}
+
+		ri, err := asn1.Marshal(keyTransRecipientInfo{
+			RID:                    asn1.RawValue{FullBytes: rid},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedKey:           ek,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling recipient info: %w", err)
+		}
+
+		ris[i] = asn1.RawValue{FullBytes: ri}
+	}
+
+	eci := encryptedContentInfo{
+		ContentType: oidData,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  spec.oid,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+	}
+
+	contentType := oidEnvelopedData
+
+	var ed []byte
+
+	if spec.gcm {
+		contentType = oidAuthEnveloped
+
+		ed, err = asn1.Marshal(authEnvelopedData{
+			RecipientInfos:           ris,
+			AuthEncryptedContentInfo: eci,
+			MAC:                      mac,
+		})
+	} else {
+		ed, err = asn1.Marshal(envelopedData{
+			RecipientInfos:       ris,
+			EncryptedContentInfo: eci,
+		})
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling enveloped data: %w", err)
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: contentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: ed},
+	})
+}
+
+// parseContentInfo parses a BER or DER encoded ContentInfo.
+func parseContentInfo(b []byte) (*contentInfo, error) {
+	der, err := ber2der(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var ci contentInfo
+
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after content info")
+	}
+
+	return &ci, nil
+}
+
+// parseEnvelope parses a BER or DER encoded ContentInfo, which must contain
+// either EnvelopedData or AuthEnvelopedData.
+func parseEnvelope(b []byte) (*envelope, error) {
+	ci, err := parseContentInfo(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ci.ContentType.Equal(oidEnvelopedData):
+		var ed envelopedData
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+			return nil, fmt.Errorf("error parsing enveloped data: %w", err)
+		}
+
+		return &envelope{
+			contentType:          ci.ContentType,
+			version:              ed.Version,
+			recipientInfos:       ed.RecipientInfos,
+			encryptedContentInfo: ed.EncryptedContentInfo,
+		}, nil
+	case ci.ContentType.Equal(oidAuthEnveloped):
+		var aed authEnvelopedData
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &aed); err != nil {
+			return nil, fmt.Errorf("error parsing auth enveloped data: %w", err)
+		}
+
+		return &envelope{
+			contentType:          ci.ContentType,
+			version:              aed.Version,
+			recipientInfos:       aed.RecipientInfos,
+			encryptedContentInfo: aed.AuthEncryptedContentInfo,
+			authAttrs:            aed.AuthAttrs,
+			mac:                  aed.MAC,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported content type '%s', expected enveloped data", ci.ContentType)
+}
+
+// recipients returns the key transport recipients of the envelope. Other
+// recipient info types are skipped.
+func (e *envelope) recipients() ([]keyTransRecipientInfo, error) {
+	var ris []keyTransRecipientInfo
+
+	for _, raw := range e.recipientInfos {
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+
+		var ri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ri); err != nil {
+			return nil, fmt.Errorf("error parsing recipient info: %w", err)
+		}
+
+		ris = append(ris, ri)
+	}
+
+	return ris, nil
+}
+
+func (e *envelope) decryptContent(key []byte) ([]byte, error) {
+	eci := e.encryptedContentInfo
+	alg := eci.ContentEncryptionAlgorithm
+
+	spec, ok := cipherSpecByOID(alg.Algorithm)
+	if !ok {
+		return nil, fmt.Errorf("unsupported content encryption algorithm '%s'", alg.Algorithm)
+	}
+
+	if len(key) != spec.keySize {
+		return nil, fmt.Errorf("content encryption key is %d bytes, expected %d", len(key), spec.keySize)
+	}
+
+	ciphertext, err := eci.ciphertext()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := spec.newBlock(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new cipher: %w", err)
+	}
+
+	if spec.gcm {
+		nonce, icvLen, err := parseGCMParameters(alg.Parameters)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(nonce) != 12 {
+			return nil, fmt.Errorf("unsupported gcm nonce size %d", len(nonce))
+		}
+
+		gcm, err := cipher.NewGCMWithTagSize(block, icvLen)
+		if err != nil {
+			return nil, fmt.Errorf("error creating new gcm: %w", err)
+		}
+
+		// The authenticated attributes are covered by the tag, using their
+		// SET OF encoding rather than the IMPLICIT [1] tag
+		var aad []byte
+		if len(e.authAttrs.FullBytes) > 0 {
+			aad = append([]byte{0x31}, e.authAttrs.FullBytes[1:]...)
+		}
+
+		return gcm.Open(nil, nonce, append(ciphertext, e.mac...), aad)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("error parsing iv: %w", err)
+	}
+
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("content encryption algorithm parameters are malformed")
+	}
+
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	return unpad(plaintext, block.BlockSize())
+}
+
+// ciphertext returns the encrypted content, which can either be a primitive
+// [0] OCTET STRING or, in BER, a constructed one made up of several
+// OCTET STRINGs.
+func (eci encryptedContentInfo) ciphertext() ([]byte, error) {
+	if !eci.EncryptedContent.IsCompound {
+		return eci.EncryptedContent.Bytes, nil
+	}
+
+	var buf bytes.Buffer
+
+	for rest := eci.EncryptedContent.Bytes; len(rest) > 0; {
+		var part []byte
+
+		var err error
+
+		rest, err = asn1.Unmarshal(rest, &part)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing encrypted content: %w", err)
+		}
+
+		buf.Write(part)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func parseGCMParameters(params asn1.RawValue) ([]byte, int, error) {
+	var p gcmParameters
+	if _, err := asn1.Unmarshal(params.FullBytes, &p); err == nil {
+		return p.Nonce, p.ICVLen, nil
+	}
+
+	var lp legacyGCMParameters
+	if _, err := asn1.Unmarshal(params.Bytes, &lp); err != nil {
+		return nil, 0, fmt.Errorf("error parsing gcm parameters: %w", err)
+	}
+
+	return lp.Nonce, lp.ICVLen, nil
+}
+
+func pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+
+	return data[:len(data)-n], nil
+}
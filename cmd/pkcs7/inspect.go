@@ -0,0 +1,559 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/output"
+)
+
+func newInspectCommand() *cobra.Command {
+	var (
+		str  string
+		file string
+		b64  bool
+	)
+
+	format := output.Text
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "inspect the structure of a pkcs7 envelope or signature",
+		Long:  "inspect the structure of a pkcs7/cms envelope or signature, without decrypting or verifying it. PEM input is detected automatically",
+		Example: `
+    # Inspect a base64 encoded envelope
+    $ genc pkcs7 inspect --string "MIIBvQYJKoZIhvcNAQcDoIIBrjCC..."
+
+    # Inspect a DER encoded signature, as JSON
+    $ genc pkcs7 inspect --file signature.p7s --base64=false --output json
+
+    # Inspect a PEM encoded certificate bundle
+    $ genc pkcs7 inspect --file bundle.p7b`,
+		Run: func(cmd *cobra.Command, args []string) {
+			in := []byte(str)
+
+			if file != "" {
+				var err error
+
+				in, err = os.ReadFile(file)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error reading file: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			der, err := decodeInput(in, b64)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error decoding input: %w", err))
+				os.Exit(1)
+			}
+
+			i, err := inspectPKCS7(der)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error inspecting pkcs7: %w", err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, i)
+			} else {
+				err = i.writeText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	inspectCmd.Flags().StringVar(&str, "string", "", "the pkcs7 string to inspect")
+	inspectCmd.Flags().StringVar(&file, "file", "", "the location of the pkcs7 file on disk")
+	inspectCmd.Flags().BoolVar(&b64, "base64", true, "whether the input is base64 encoded (ignored for PEM input)")
+	inspectCmd.Flags().Var(&format, "output", "the output format")
+
+	inspectCmd.MarkFlagsOneRequired("string", "file")
+	inspectCmd.MarkFlagsMutuallyExclusive("string", "file")
+
+	return inspectCmd
+}
+
+// decodeInput returns the DER bytes of a PEM, base64 or DER encoded input.
+func decodeInput(in []byte, b64 bool) ([]byte, error) {
+	if bytes.Contains(in, []byte("-----BEGIN")) {
+		b, _ := pem.Decode(in)
+		if b == nil {
+			return nil, errors.New("unable to decode PEM input")
+		}
+
+		return b.Bytes, nil
+	}
+
+	if !b64 {
+		return in, nil
+	}
+
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(in)), ""))
+}
+
+var oidNames = map[string]string{
+	"1.2.840.113549.1.7.1":       "data",
+	"1.2.840.113549.1.7.2":       "signedData",
+	"1.2.840.113549.1.7.3":       "envelopedData",
+	"1.2.840.113549.1.7.5":       "digestedData",
+	"1.2.840.113549.1.7.6":       "encryptedData",
+	"1.2.840.113549.1.9.16.1.2":  "authData",
+	"1.2.840.113549.1.9.16.1.9":  "compressedData",
+	"1.2.840.113549.1.9.16.1.23": "authEnvelopedData",
+	"1.2.840.113549.1.1.1":       "rsaEncryption",
+	"1.2.840.113549.1.1.5":       "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.7":       "rsaesOaep",
+	"1.2.840.113549.1.1.10":      "rsassaPss",
+	"1.2.840.113549.1.1.11":      "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12":      "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13":      "sha512WithRSAEncryption",
+	"1.2.840.10045.2.1":          "ecPublicKey",
+	"1.2.840.10045.4.3.2":        "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":        "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":        "ecdsa-with-SHA512",
+	"1.3.101.112":                "ed25519",
+	"1.2.840.113549.2.5":         "md5",
+	"1.3.14.3.2.26":              "sha1",
+	"2.16.840.1.101.3.4.2.4":     "sha224",
+	"2.16.840.1.101.3.4.2.1":     "sha256",
+	"2.16.840.1.101.3.4.2.2":     "sha384",
+	"2.16.840.1.101.3.4.2.3":     "sha512",
+	"2.16.840.1.101.3.4.1.2":     "aes128-cbc",
+	"2.16.840.1.101.3.4.1.22":    "aes192-cbc",
+	"2.16.840.1.101.3.4.1.42":    "aes256-cbc",
+	"2.16.840.1.101.3.4.1.6":     "aes128-gcm",
+	"2.16.840.1.101.3.4.1.26":    "aes192-gcm",
+	"2.16.840.1.101.3.4.1.46":    "aes256-gcm",
+	"2.16.840.1.101.3.4.1.5":     "aes128-wrap",
+	"2.16.840.1.101.3.4.1.45":    "aes256-wrap",
+	"1.2.840.113549.3.2":         "rc2-cbc",
+	"1.2.840.113549.3.7":         "des-ede3-cbc",
+	"1.3.14.3.2.7":               "des-cbc",
+	"1.2.840.113549.1.9.3":       "contentType",
+	"1.2.840.113549.1.9.4":       "messageDigest",
+	"1.2.840.113549.1.9.5":       "signingTime",
+	"1.2.840.113549.1.9.15":      "smimeCapabilities",
+	"1.2.840.113549.1.9.16.2.12": "signingCertificate",
+	"1.2.840.113549.1.9.16.2.47": "signingCertificateV2",
+	"1.2.840.113549.1.9.52":      "cmsAlgorithmProtection",
+}
+
+// oidName returns a readable name for oid, including the dotted form.
+func oidName(oid asn1.ObjectIdentifier) string {
+	if n, ok := oidNames[oid.String()]; ok {
+		return fmt.Sprintf("%s (%s)", n, oid)
+	}
+
+	return oid.String()
+}
+
+type inspection struct {
+	ContentType string `json:"contentType"`
+	Version     int    `json:"version"`
+
+	// Enveloped data
+	Recipients        []recipientDetails `json:"recipients,omitempty"`
+	ContentEncryption *contentEncryption `json:"contentEncryption,omitempty"`
+
+	// Signed data
+	DigestAlgorithms        []string        `json:"digestAlgorithms,omitempty"`
+	EncapsulatedContentType string          `json:"encapsulatedContentType,omitempty"`
+	Detached                bool            `json:"detached,omitempty"`
+	Signers                 []signerDetails `json:"signers,omitempty"`
+
+	Certificates []certificateDetails `json:"certificates,omitempty"`
+	CRLs         []crlDetails         `json:"crls,omitempty"`
+}
+
+type recipientDetails struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	identifier
+	KeyEncryptionAlgorithm string `json:"keyEncryptionAlgorithm,omitempty"`
+}
+
+type contentEncryption struct {
+	Algorithm              string `json:"algorithm"`
+	IV                     string `json:"iv,omitempty"`
+	Nonce                  string `json:"nonce,omitempty"`
+	ICVLength              int    `json:"icvLength,omitempty"`
+	MAC                    string `json:"mac,omitempty"`
+	EncryptedContentLength int    `json:"encryptedContentLength"`
+}
+
+type signerDetails struct {
+	Version int `json:"version"`
+	identifier
+	DigestAlgorithm    string     `json:"digestAlgorithm"`
+	SignatureAlgorithm string     `json:"signatureAlgorithm"`
+	SignedAttributes   []string   `json:"signedAttributes,omitempty"`
+	SigningTime        *time.Time `json:"signingTime,omitempty"`
+}
+
+type certificateDetails struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+}
+
+type crlDetails struct {
+	Issuer     string    `json:"issuer"`
+	ThisUpdate time.Time `json:"thisUpdate"`
+	NextUpdate time.Time `json:"nextUpdate"`
+	Revoked    int       `json:"revoked"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+var (
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttributeSignTime = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// recipientTypes are the RecipientInfo choices, keyed by their implicit tag.
+// Key transport recipients are a SEQUENCE and aren't tagged.
+var recipientTypes = map[int]string{
+	1: "keyAgreement",
+	2: "kek",
+	3: "password",
+	4: "other",
+}
+
+// inspectPKCS7 describes a DER or BER encoded ContentInfo.
+func inspectPKCS7(b []byte) (*inspection, error) {
+	ci, err := parseContentInfo(b)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &inspection{ContentType: oidName(ci.ContentType)}
+
+	switch {
+	case ci.ContentType.Equal(oidEnvelopedData), ci.ContentType.Equal(oidAuthEnveloped):
+		e, err := parseEnvelope(b)
+		if err != nil {
+			return nil, err
+		}
+
+		i.inspectEnvelope(e)
+	case ci.ContentType.Equal(oidSignedData):
+		var sd signedData
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+			return nil, fmt.Errorf("error parsing signed data: %w", err)
+		}
+
+		if err := i.inspectSignedData(&sd); err != nil {
+			return nil, err
+		}
+	}
+
+	return i, nil
+}
+
+func (i *inspection) inspectEnvelope(e *envelope) {
+	i.Version = e.version
+
+	for _, raw := range e.recipientInfos {
+		if raw.Class != asn1.ClassUniversal {
+			i.Recipients = append(i.Recipients, recipientDetails{Type: recipientTypes[raw.Tag]})
+			continue
+		}
+
+		var ri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ri); err != nil {
+			i.Recipients = append(i.Recipients, recipientDetails{Type: "keyTransport (unparseable)"})
+			continue
+		}
+
+		i.Recipients = append(i.Recipients, recipientDetails{
+			Type:                   "keyTransport",
+			Version:                ri.Version,
+			identifier:             parseIdentifier(ri.RID),
+			KeyEncryptionAlgorithm: oidName(ri.KeyEncryptionAlgorithm.Algorithm),
+		})
+	}
+
+	eci := e.encryptedContentInfo
+	alg := eci.ContentEncryptionAlgorithm
+
+	ce := &contentEncryption{Algorithm: oidName(alg.Algorithm)}
+
+	if ct, err := eci.ciphertext(); err == nil {
+		ce.EncryptedContentLength = len(ct)
+	}
+
+	if spec, ok := cipherSpecByOID(alg.Algorithm); ok && spec.gcm {
+		if nonce, icvLen, err := parseGCMParameters(alg.Parameters); err == nil {
+			ce.Nonce = hex.EncodeToString(nonce)
+			ce.ICVLength = icvLen
+		}
+	} else {
+		var iv []byte
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &iv); err == nil {
+			ce.IV = hex.EncodeToString(iv)
+		}
+	}
+
+	if len(e.mac) > 0 {
+		ce.MAC = hex.EncodeToString(e.mac)
+	}
+
+	i.ContentEncryption = ce
+}
+
+func (i *inspection) inspectSignedData(sd *signedData) error {
+	i.Version = sd.Version
+	i.EncapsulatedContentType = oidName(sd.EncapContentInfo.ContentType)
+	i.Detached = len(sd.EncapContentInfo.Content.FullBytes) == 0
+
+	for _, da := range sd.DigestAlgorithms {
+		i.DigestAlgorithms = append(i.DigestAlgorithms, oidName(da.Algorithm))
+	}
+
+	for _, raw := range sd.SignerInfos {
+		var si signerInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &si); err != nil {
+			return fmt.Errorf("error parsing signer info: %w", err)
+		}
+
+		sdt := signerDetails{
+			Version:            si.Version,
+			identifier:         parseIdentifier(si.SID),
+			DigestAlgorithm:    oidName(si.DigestAlgorithm.Algorithm),
+			SignatureAlgorithm: oidName(si.SignatureAlgorithm.Algorithm),
+		}
+
+		err := forEachElement(si.SignedAttrs.Bytes, func(raw asn1.RawValue) error {
+			var a attribute
+			if _, err := asn1.Unmarshal(raw.FullBytes, &a); err != nil {
+				return fmt.Errorf("error parsing signed attribute: %w", err)
+			}
+
+			sdt.SignedAttributes = append(sdt.SignedAttributes, oidName(a.Type))
+
+			if a.Type.Equal(oidAttributeSignTime) {
+				var t time.Time
+				if _, err := asn1.Unmarshal(a.Values.Bytes, &t); err == nil {
+					sdt.SigningTime = &t
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		i.Signers = append(i.Signers, sdt)
+	}
+
+	// Only X.509 certificates are described, other choices (such as attribute
+	// certificates) are tagged and skipped
+	err := forEachElement(sd.Certificates.Bytes, func(raw asn1.RawValue) error {
+		if raw.Class != asn1.ClassUniversal {
+			return nil
+		}
+
+		c, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate: %w", err)
+		}
+
+		i.Certificates = append(i.Certificates, certificateDetails{
+			Subject:      c.Subject.String(),
+			Issuer:       c.Issuer.String(),
+			SerialNumber: c.SerialNumber.Text(16),
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return forEachElement(sd.CRLs.Bytes, func(raw asn1.RawValue) error {
+		if raw.Class != asn1.ClassUniversal {
+			return nil
+		}
+
+		rl, err := x509.ParseRevocationList(raw.FullBytes)
+		if err != nil {
+			return fmt.Errorf("error parsing crl: %w", err)
+		}
+
+		i.CRLs = append(i.CRLs, crlDetails{
+			Issuer:     rl.Issuer.String(),
+			ThisUpdate: rl.ThisUpdate,
+			NextUpdate: rl.NextUpdate,
+			Revoked:    len(rl.RevokedCertificateEntries),
+		})
+
+		return nil
+	})
+}
+
+// forEachElement calls fn for each element in the contents of a SET or
+// SEQUENCE.
+func forEachElement(b []byte, fn func(asn1.RawValue) error) error {
+	for len(b) > 0 {
+		var raw asn1.RawValue
+
+		var err error
+
+		b, err = asn1.Unmarshal(b, &raw)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *inspection) writeText(w io.Writer) error {
+	var sb strings.Builder
+
+	field := func(label string, value interface{}) {
+		fmt.Fprintf(&sb, "%-24s%v\n", label+":", value)
+	}
+
+	heading := func(h string) {
+		fmt.Fprintln(&sb)
+		fmt.Fprintln(&sb, h)
+		fmt.Fprintln(&sb, "------------------------")
+	}
+
+	id := func(id identifier) {
+		if id.SubjectKeyID != "" {
+			field("Subject Key Identifier", id.SubjectKeyID)
+			return
+		}
+
+		field("Issuer", id.Issuer)
+		field("Serial", id.SerialNumber)
+	}
+
+	field("Content Type", i.ContentType)
+	field("Version", i.Version)
+
+	if ce := i.ContentEncryption; ce != nil {
+		heading("Content Encryption")
+		field("Algorithm", ce.Algorithm)
+
+		if ce.IV != "" {
+			field("IV", ce.IV)
+		}
+
+		if ce.Nonce != "" {
+			field("Nonce", ce.Nonce)
+			field("ICV Length", ce.ICVLength)
+		}
+
+		if ce.MAC != "" {
+			field("MAC", ce.MAC)
+		}
+
+		field("Encrypted Length", ce.EncryptedContentLength)
+	}
+
+	for n, r := range i.Recipients {
+		heading(fmt.Sprintf("Recipient %d", n+1))
+		field("Type", r.Type)
+
+		if r.KeyEncryptionAlgorithm != "" {
+			field("Version", r.Version)
+			id(r.identifier)
+			field("Key Encryption", r.KeyEncryptionAlgorithm)
+		}
+	}
+
+	if i.EncapsulatedContentType != "" {
+		field("Digest Algorithms", strings.Join(i.DigestAlgorithms, ", "))
+		field("Encapsulated Content", i.EncapsulatedContentType)
+		field("Detached", i.Detached)
+	}
+
+	for n, s := range i.Signers {
+		heading(fmt.Sprintf("Signer %d", n+1))
+		field("Version", s.Version)
+		id(s.identifier)
+		field("Digest Algorithm", s.DigestAlgorithm)
+		field("Signature Algorithm", s.SignatureAlgorithm)
+
+		if len(s.SignedAttributes) > 0 {
+			field("Signed Attributes", strings.Join(s.SignedAttributes, ", "))
+		}
+
+		if s.SigningTime != nil {
+			field("Signing Time", s.SigningTime.Format(time.RFC3339))
+		}
+	}
+
+	for n, c := range i.Certificates {
+		heading(fmt.Sprintf("Certificate %d", n+1))
+		field("Subject", c.Subject)
+		field("Issuer", c.Issuer)
+		field("Serial", c.SerialNumber)
+		field("Not Before", c.NotBefore.Format(time.RFC3339))
+		field("Not After", c.NotAfter.Format(time.RFC3339))
+	}
+
+	for n, c := range i.CRLs {
+		heading(fmt.Sprintf("CRL %d", n+1))
+		field("Issuer", c.Issuer)
+		field("This Update", c.ThisUpdate.Format(time.RFC3339))
+		field("Next Update", c.NextUpdate.Format(time.RFC3339))
+		field("Revoked", c.Revoked)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
@@ -0,0 +1,93 @@
+package pkcs7
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+func TestInspectPKCS7(t *testing.T) {
+	// Generate new Private Key
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	// Generate a new X509 Certificate Template
+	tmpl, err := certTemplate()
+	if err != nil {
+		t.Errorf("certTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	// Create Certificate DER
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Errorf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Errorf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	t.Run("EnvelopedData", func(t *testing.T) {
+		enc, err := encryptPKCS7("wibble", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), contentCipherAES128CBC)
+		if err != nil {
+			t.Errorf("encryptPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		i, err := inspectPKCS7(enc)
+		if err != nil {
+			t.Fatalf("inspectPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		if i.ContentType != "envelopedData (1.2.840.113549.1.7.3)" {
+			t.Errorf("content type was expected to be envelopedData but was '%s'", i.ContentType)
+		}
+
+		if i.ContentEncryption == nil || i.ContentEncryption.Algorithm != "aes128-cbc (2.16.840.1.101.3.4.1.2)" || len(i.ContentEncryption.IV) != 32 {
+			t.Errorf("content encryption was not as expected: %+v", i.ContentEncryption)
+		}
+
+		if len(i.Recipients) != 1 || i.Recipients[0].SerialNumber != tmpl.SerialNumber.Text(16) {
+			t.Errorf("recipients were not as expected: %+v", i.Recipients)
+		}
+	})
+
+	t.Run("SignedData", func(t *testing.T) {
+		sd, err := pkcs7.NewSignedData([]byte("wibble"))
+		if err != nil {
+			t.Errorf("NewSignedData returned an error when one wasn't expected: %+v", err)
+		}
+
+		if err := sd.AddSigner(cert, privateKey, pkcs7.SignerInfoConfig{}); err != nil {
+			t.Errorf("AddSigner returned an error when one wasn't expected: %+v", err)
+		}
+
+		signed, err := sd.Finish()
+		if err != nil {
+			t.Errorf("Finish returned an error when one wasn't expected: %+v", err)
+		}
+
+		i, err := inspectPKCS7(signed)
+		if err != nil {
+			t.Fatalf("inspectPKCS7 returned an error when one wasn't expected: %+v", err)
+		}
+
+		if i.Detached {
+			t.Error("signed data was not expected to be detached")
+		}
+
+		if len(i.Signers) != 1 || i.Signers[0].SerialNumber != tmpl.SerialNumber.Text(16) || i.Signers[0].SigningTime == nil {
+			t.Errorf("signers were not as expected: %+v", i.Signers)
+		}
+
+		if len(i.Certificates) != 1 || i.Certificates[0].Subject != cert.Subject.String() {
+			t.Errorf("certificates were not as expected: %+v", i.Certificates)
+		}
+	})
+}
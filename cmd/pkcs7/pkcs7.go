@@ -12,6 +12,7 @@ func NewCommand() *cobra.Command {
 
 	cmd.AddCommand(newDecryptCommand())
 	cmd.AddCommand(newEncryptCommand())
+	cmd.AddCommand(newInspectCommand())
 
 	return cmd
 }
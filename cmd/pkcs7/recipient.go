@@ -0,0 +1,213 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// subjectKeyID returns the subject key identifier of the recipient, or nil
+// if the recipient is identified by issuer and serial number.
+func (ri keyTransRecipientInfo) subjectKeyID() []byte {
+	if ri.RID.Class == asn1.ClassContextSpecific && ri.RID.Tag == 0 {
+		return ri.RID.Bytes
+	}
+
+	return nil
+}
+
+func (ri keyTransRecipientInfo) issuerAndSerial() (*issuerAndSerial, error) {
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(ri.RID.FullBytes, &ias); err != nil {
+		return nil, err
+	}
+
+	return &ias, nil
+}
+
+// matchesCertificate determines if the recipient identifier refers to cert.
+func (ri keyTransRecipientInfo) matchesCertificate(cert *x509.Certificate) bool {
+	if ski := ri.subjectKeyID(); ski != nil {
+		return len(cert.SubjectKeyId) > 0 && bytes.Equal(ski, cert.SubjectKeyId)
+	}
+
+	ias, err := ri.issuerAndSerial()
+	if err != nil {
+		return false
+	}
+
+	return ias.SerialNumber.Cmp(cert.SerialNumber) == 0 && bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer)
+}
+
+func (ri keyTransRecipientInfo) String() string {
+	return parseIdentifier(ri.RID).String()
+}
+
+// identifier describes a recipient or signer identifier, which is either an
+// IssuerAndSerialNumber or a [0] SubjectKeyIdentifier.
+type identifier struct {
+	Issuer       string `json:"issuer,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	SubjectKeyID string `json:"subjectKeyIdentifier,omitempty"`
+}
+
+func parseIdentifier(raw asn1.RawValue) identifier {
+	if raw.Class == asn1.ClassContextSpecific && raw.Tag == 0 {
+		return identifier{SubjectKeyID: hex.EncodeToString(raw.Bytes)}
+	}
+
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(raw.FullBytes, &ias); err != nil {
+		return identifier{}
+	}
+
+	return identifier{Issuer: issuerName(ias.Issuer.FullBytes), SerialNumber: ias.SerialNumber.Text(16)}
+}
+
+func (id identifier) String() string {
+	switch {
+	case id.SubjectKeyID != "":
+		return fmt.Sprintf("subject key identifier=%s", id.SubjectKeyID)
+	case id.SerialNumber != "":
+		return fmt.Sprintf("issuer=%s, serial=%s", id.Issuer, id.SerialNumber)
+	}
+
+	return "unparseable identifier"
+}
+
+func issuerName(der []byte) string {
+	var rdn pkix.RDNSequence
+	if _, err := asn1.Unmarshal(der, &rdn); err != nil {
+		return "<unparseable>"
+	}
+
+	var n pkix.Name
+	n.FillFromRDNSequence(&rdn)
+
+	return n.String()
+}
+
+// decrypt decrypts the envelope content for the recipient matching cert.
+//
+// If cert is nil, the recipient is located using the private key. Recipients
+// identified by a subject key identifier are matched against the public half
+// of the key, while those identified by issuer and serial number are tried in
+// turn, as they can't be matched without the certificate.
+func (e *envelope) decrypt(cert *x509.Certificate, pk crypto.PrivateKey) ([]byte, error) {
+	priv, ok := pk.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type '%T', only RSA keys are supported", pk)
+	}
+
+	ris, err := e.recipients()
+	if err != nil {
+		return nil, err
+	}
+
+	if cert != nil {
+		if !priv.PublicKey.Equal(cert.PublicKey) {
+			return nil, errors.New("the private key does not match the provided certificate, check them with genc key match")
+		}
+
+		for _, ri := range ris {
+			if ri.matchesCertificate(cert) {
+				return e.decryptFor(ri, priv)
+			}
+		}
+
+		return nil, noRecipientError("the provided certificate", ris, nil)
+	}
+
+	ski, err := subjectKeyID(&priv.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating subject key identifier: %w", err)
+	}
+
+	for _, ri := range ris {
+		if id := ri.subjectKeyID(); id != nil && bytes.Equal(id, ski) {
+			return e.decryptFor(ri, priv)
+		}
+	}
+
+	// The last error is kept, so that a key that doesn't belong to any of the
+	// recipients can be told apart from an envelope without one to try
+	var lastErr error
+
+	for _, ri := range ris {
+		if ri.subjectKeyID() != nil {
+			continue
+		}
+
+		b, err := e.decryptFor(ri, priv)
+		if err == nil {
+			return b, nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, noRecipientError("the private key", ris, lastErr)
+}
+
+func (e *envelope) decryptFor(ri keyTransRecipientInfo, priv *rsa.PrivateKey) ([]byte, error) {
+	key, err := rsa.DecryptPKCS1v15(rand.Reader, priv, ri.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting content encryption key: %w", err)
+	}
+
+	return e.decryptContent(key)
+}
+
+// noRecipientError describes the recipients of an envelope that don't match
+// what, wrapping err, the last error from trying to decrypt for a recipient,
+// if there was one.
+func noRecipientError(what string, ris []keyTransRecipientInfo, err error) error {
+	if len(ris) == 0 {
+		return errors.New("the envelope does not contain any key transport recipients")
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "no recipient in the envelope matches %s, the envelope contains:", what)
+
+	for _, ri := range ris {
+		fmt.Fprintf(&sb, "\n  %s", ri)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s\ndecrypting for the issuer and serial number recipients failed: %w", sb.String(), err)
+	}
+
+	return errors.New(sb.String())
+}
+
+// subjectKeyID calculates the subject key identifier of pub, using the
+// SHA-1 hash of the subject public key (method 1 of RFC 5280 4.2.1.2).
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+
+	h := sha1.Sum(spki.PublicKey.Bytes)
+
+	return h[:], nil
+}
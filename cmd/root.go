@@ -7,11 +7,16 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/simondrake/genc/cmd/aesgcm"
+	"github.com/simondrake/genc/cmd/ca"
+	"github.com/simondrake/genc/cmd/cert"
 	"github.com/simondrake/genc/cmd/cidr"
+	"github.com/simondrake/genc/cmd/csr"
 	"github.com/simondrake/genc/cmd/ip"
 	"github.com/simondrake/genc/cmd/jwt"
+	"github.com/simondrake/genc/cmd/key"
 	"github.com/simondrake/genc/cmd/pkcs7"
 	"github.com/simondrake/genc/cmd/rc4"
+	"github.com/simondrake/genc/cmd/tls"
 	"github.com/simondrake/genc/cmd/version"
 )
 
@@ -29,6 +34,11 @@ func Execute() {
 	rootCmd.AddCommand(jwt.NewCommand())
 	rootCmd.AddCommand(cidr.NewCommand())
 	rootCmd.AddCommand(ip.NewCommand())
+	rootCmd.AddCommand(key.NewCommand())
+	rootCmd.AddCommand(csr.NewCommand())
+	rootCmd.AddCommand(cert.NewCommand())
+	rootCmd.AddCommand(ca.NewCommand())
+	rootCmd.AddCommand(tls.NewCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
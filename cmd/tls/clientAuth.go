@@ -0,0 +1,51 @@
+// clientAuth implements a custom type to be used with Cobra.
+//
+// It ensures that the client authentication policy is one of none, request,
+// require, or verify.
+
+package tls
+
+import (
+	"crypto/tls"
+	"errors"
+)
+
+type clientAuth string
+
+const (
+	clientAuthNone    clientAuth = "none"
+	clientAuthRequest clientAuth = "request"
+	clientAuthRequire clientAuth = "require"
+	clientAuthVerify  clientAuth = "verify"
+)
+
+func (c *clientAuth) String() string {
+	return string(*c)
+}
+
+func (c *clientAuth) Set(v string) error {
+	switch clientAuth(v) {
+	case clientAuthNone, clientAuthRequest, clientAuthRequire, clientAuthVerify:
+		*c = clientAuth(v)
+		return nil
+	default:
+		return errors.New(`must be one of none, request, require, or verify`)
+	}
+}
+
+func (c *clientAuth) Type() string {
+	return "[none,request,require,verify]"
+}
+
+func (c clientAuth) tlsClientAuth() tls.ClientAuthType {
+	switch c {
+	case clientAuthRequest:
+		return tls.RequestClientCert
+	case clientAuthRequire:
+		return tls.RequireAnyClientCert
+	case clientAuthVerify:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
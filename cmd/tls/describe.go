@@ -0,0 +1,165 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/simondrake/genc/internal/certs"
+)
+
+// helloDetails describes the ClientHello message sent by a client.
+type helloDetails struct {
+	Event            string    `json:"event"`
+	Time             time.Time `json:"time"`
+	Remote           string    `json:"remote"`
+	ServerName       string    `json:"serverName,omitempty"`
+	Versions         []string  `json:"versions"`
+	CipherSuites     []string  `json:"cipherSuites"`
+	ALPN             []string  `json:"alpn,omitempty"`
+	SignatureSchemes []string  `json:"signatureSchemes,omitempty"`
+	Curves           []string  `json:"curves,omitempty"`
+}
+
+// connectionDetails describes the parameters negotiated in a handshake.
+type connectionDetails struct {
+	Event        string                     `json:"event,omitempty"`
+	Time         time.Time                  `json:"time"`
+	Remote       string                     `json:"remote"`
+	ServerName   string                     `json:"serverName,omitempty"`
+	Version      string                     `json:"version"`
+	CipherSuite  string                     `json:"cipherSuite"`
+	ALPN         string                     `json:"alpn,omitempty"`
+	Resumed      bool                       `json:"resumed"`
+	OCSPStapled  bool                       `json:"ocspStapled"`
+	Certificates []certs.CertificateDetails `json:"certificates,omitempty"`
+}
+
+func describeHello(hello *tls.ClientHelloInfo, now time.Time) *helloDetails {
+	d := &helloDetails{
+		Event:      "clientHello",
+		Time:       now,
+		Remote:     hello.Conn.RemoteAddr().String(),
+		ServerName: hello.ServerName,
+		ALPN:       hello.SupportedProtos,
+	}
+
+	for _, v := range hello.SupportedVersions {
+		d.Versions = append(d.Versions, tls.VersionName(v))
+	}
+
+	for _, cs := range hello.CipherSuites {
+		d.CipherSuites = append(d.CipherSuites, tls.CipherSuiteName(cs))
+	}
+
+	for _, s := range hello.SignatureSchemes {
+		d.SignatureSchemes = append(d.SignatureSchemes, s.String())
+	}
+
+	for _, c := range hello.SupportedCurves {
+		d.Curves = append(d.Curves, c.String())
+	}
+
+	return d
+}
+
+// describeConnection describes the negotiated parameters of cs, along with
+// the certificates presented by the peer.
+func describeConnection(remote string, cs tls.ConnectionState, now time.Time) *connectionDetails {
+	d := &connectionDetails{
+		Time:        now,
+		Remote:      remote,
+		ServerName:  cs.ServerName,
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ALPN:        cs.NegotiatedProtocol,
+		Resumed:     cs.DidResume,
+		OCSPStapled: len(cs.OCSPResponse) > 0,
+	}
+
+	for _, c := range cs.PeerCertificates {
+		d.Certificates = append(d.Certificates, certs.DescribeCertificate(c, now))
+	}
+
+	return d
+}
+
+func field(sb *strings.Builder, label string, value interface{}) {
+	fmt.Fprintf(sb, "%-24s%v\n", label+":", value)
+}
+
+func list(sb *strings.Builder, label string, values []string) {
+	if len(values) == 0 {
+		field(sb, label, "none")
+		return
+	}
+
+	field(sb, label, strings.Join(values, ", "))
+}
+
+// WriteText writes d in a human readable form.
+func (d *helloDetails) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Client Hello from %s\n", d.Remote)
+	fmt.Fprintln(&sb, "------------------------")
+	field(&sb, "Time", d.Time.Format(time.RFC3339))
+	field(&sb, "Server Name", orNone(d.ServerName))
+	list(&sb, "Versions", d.Versions)
+	list(&sb, "Cipher Suites", d.CipherSuites)
+	list(&sb, "ALPN", d.ALPN)
+	list(&sb, "Signature Schemes", d.SignatureSchemes)
+	list(&sb, "Curves", d.Curves)
+	fmt.Fprintln(&sb)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// WriteText writes d in a human readable form.
+func (d *connectionDetails) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Handshake with %s\n", d.Remote)
+	fmt.Fprintln(&sb, "------------------------")
+	d.writeFields(&sb)
+	field(&sb, "Client Certificates", len(d.Certificates))
+
+	if len(d.Certificates) > 0 {
+		fmt.Fprintln(&sb)
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+
+	if len(d.Certificates) > 0 {
+		if err := (&certs.Details{Certificates: d.Certificates}).WriteText(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func (d *connectionDetails) writeFields(sb *strings.Builder) {
+	field(sb, "Time", d.Time.Format(time.RFC3339))
+	field(sb, "Server Name", orNone(d.ServerName))
+	field(sb, "Version", d.Version)
+	field(sb, "Cipher Suite", d.CipherSuite)
+	field(sb, "ALPN", orNone(d.ALPN))
+	field(sb, "Resumed", d.Resumed)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
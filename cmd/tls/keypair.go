@@ -0,0 +1,80 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/keys"
+)
+
+// loadKeyPair loads the certificate chain in certFile and the matching
+// private key in keyFile.
+func loadKeyPair(certFile, keyFile, passphraseFile string) (tls.Certificate, error) {
+	chain, err := certs.LoadCertificates(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	key, err := keys.LoadPrivateKey(keyFile, keys.Passphrase(passphraseFile))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	pub, err := keys.PublicKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if k, ok := pub.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(chain[0].PublicKey) {
+		return tls.Certificate{}, errors.New("the private key does not match the certificate, check them with genc key match")
+	}
+
+	return keyPair(chain, key), nil
+}
+
+// selfSigned returns a short lived, self-signed certificate for localhost.
+func selfSigned() (tls.Certificate, error) {
+	key, err := keys.Generate(keys.ECDSA, 0, "P-256")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	tmpl, err := certs.NewTemplate(pkix.Name{CommonName: "localhost"}, time.Now().Add(24*time.Hour))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	tmpl.DNSNames = []string{"localhost"}
+	tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return keyPair([]*x509.Certificate{c}, key), nil
+}
+
+func keyPair(chain []*x509.Certificate, key crypto.PrivateKey) tls.Certificate {
+	kp := tls.Certificate{PrivateKey: key, Leaf: chain[0]}
+
+	for _, c := range chain {
+		kp.Certificate = append(kp.Certificate, c.Raw)
+	}
+
+	return kp
+}
@@ -0,0 +1,235 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/output"
+)
+
+type probeOptions struct {
+	serverName  string
+	alpn        []string
+	roots       *x509.CertPool
+	certificate *tls.Certificate
+	timeout     time.Duration
+}
+
+// alertWait is how long to wait for an alert once the handshake has
+// completed. TLS 1.3 servers reject client certificates after the client has
+// sent its Finished message, so the rejection is only seen on the next read.
+const alertWait = time.Second
+
+// probeResult describes a connection to a TLS server, and the chain it
+// served.
+type probeResult struct {
+	Address      string              `json:"address"`
+	Connection   *connectionDetails  `json:"connection"`
+	Verification *certs.Verification `json:"verification"`
+	// Alert is the error the server sent once the handshake completed, such
+	// as when it rejects the client certificate
+	Alert string `json:"alert,omitempty"`
+}
+
+func newProbeCommand() *cobra.Command {
+	var (
+		rootsFile      string
+		certFile       string
+		keyFile        string
+		passphraseFile string
+	)
+
+	opts := probeOptions{}
+	format := output.Text
+
+	probeCmd := &cobra.Command{
+		Use:   "probe <host[:port]>",
+		Short: "connect to a TLS server and report the negotiated parameters and served chain",
+		Long: `connect to a TLS server and report the negotiated parameters and served chain
+
+The served chain is verified against the roots, and for the server name, but the connection is made regardless, so the details of invalid chains can be inspected.
+The exit code is 1 if the chain is invalid, or if the server sends an alert once the handshake has completed, as TLS 1.3
+servers do when they reject the client certificate.`,
+		Example: `
+    # Probe a public server
+    $ genc tls probe example.com
+
+    # Probe a local genc tls serve, trusting the genc ca root and presenting a client certificate
+    $ genc tls probe localhost:8443 --roots ca/root.crt --cert client.crt --key client.key
+
+    # Probe an IP address, sending an SNI and offering http/1.1 only
+    $ genc tls probe 10.0.0.1:443 --servername example.com --alpn http/1.1 --output json`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+
+			if rootsFile != "" {
+				if opts.roots, err = certs.LoadPool(rootsFile); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading roots: %w", err))
+					os.Exit(1)
+				}
+			}
+
+			if certFile != "" {
+				kp, err := loadKeyPair(certFile, keyFile, passphraseFile)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading client certificate: %w", err))
+					os.Exit(1)
+				}
+
+				opts.certificate = &kp
+			}
+
+			r, err := probe(args[0], opts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error probing %s: %w", args[0], err))
+				os.Exit(1)
+			}
+
+			if format == output.JSON {
+				err = output.WriteJSON(os.Stdout, r)
+			} else {
+				err = r.WriteText(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+				os.Exit(1)
+			}
+
+			if !r.Verification.Valid || r.Alert != "" {
+				os.Exit(1)
+			}
+		},
+	}
+
+	probeCmd.Flags().StringVar(&opts.serverName, "servername", "", "the server name to send in the SNI extension and verify the chain for, defaults to the host")
+	probeCmd.Flags().StringSliceVar(&opts.alpn, "alpn", []string{"h2", "http/1.1"}, "the ALPN protocols to offer")
+	probeCmd.Flags().StringVar(&rootsFile, "roots", "", "the location of a bundle of trusted root certificates on disk, defaults to the system roots")
+	probeCmd.Flags().StringVar(&certFile, "cert", "", "the location of a client certificate on disk, followed by any intermediates")
+	probeCmd.Flags().StringVar(&keyFile, "key", "", "the location of the client private key on disk")
+	probeCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the client private key passphrase on disk, prompted for if not provided")
+	probeCmd.Flags().DurationVar(&opts.timeout, "timeout", 10*time.Second, "how long to wait for the connection and handshake")
+	probeCmd.Flags().Var(&format, "output", "the output format")
+
+	probeCmd.MarkFlagsRequiredTogether("cert", "key")
+
+	return probeCmd
+}
+
+// probe connects to addr, which defaults to port 443, and describes the
+// handshake and the chain the server presented.
+func probe(addr string, opts probeOptions) (*probeResult, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = strings.Trim(addr, "[]")
+		addr = net.JoinHostPort(host, "443")
+	}
+
+	if opts.serverName == "" {
+		opts.serverName = host
+	}
+
+	cfg := &tls.Config{
+		ServerName: opts.serverName,
+		NextProtos: opts.alpn,
+		MinVersion: tls.VersionTLS10,
+		// The chain is verified separately, so that every problem with it
+		// can be reported
+		InsecureSkipVerify: true,
+	}
+
+	if opts.certificate != nil {
+		cfg.Certificates = []tls.Certificate{*opts.certificate}
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: opts.timeout}, "tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	cs := conn.ConnectionState()
+	now := time.Now()
+
+	r := &probeResult{
+		Address:    conn.RemoteAddr().String(),
+		Connection: describeConnection(conn.RemoteAddr().String(), cs, now),
+	}
+
+	if err := readAlert(conn); err != nil {
+		r.Alert = err.Error()
+	}
+
+	r.Verification = certs.Verify(cs.PeerCertificates[0], certs.VerifyOptions{
+		Roots:         opts.roots,
+		Intermediates: cs.PeerCertificates[1:],
+		Time:          now,
+		Hostname:      opts.serverName,
+		ExtKeyUsages:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	return r, nil
+}
+
+// readAlert waits briefly for the server to send anything after the
+// handshake, returning the error if it sends an alert.
+//
+// Servers that wait for a request time out, and those that speak first, such
+// as SMTP, or close the connection, aren't treated as errors.
+func readAlert(conn *tls.Conn) error {
+	if err := conn.SetReadDeadline(time.Now().Add(alertWait)); err != nil {
+		return err
+	}
+
+	_, err := conn.Read(make([]byte, 1))
+
+	var ne net.Error
+	if err == nil || errors.Is(err, io.EOF) || (errors.As(err, &ne) && ne.Timeout()) {
+		return nil
+	}
+
+	return err
+}
+
+// WriteText writes r in a human readable form.
+func (r *probeResult) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	field(&sb, "Address", r.Address)
+
+	if r.Alert != "" {
+		field(&sb, "Alert", r.Alert)
+	}
+
+	r.Connection.writeFields(&sb)
+	field(&sb, "OCSP Stapled", r.Connection.OCSPStapled)
+	fmt.Fprintln(&sb)
+
+	fmt.Fprintln(&sb, "Verification")
+	fmt.Fprintln(&sb, "------------------------")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+
+	if err := r.Verification.WriteText(w); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+
+	return (&certs.Details{Certificates: r.Connection.Certificates}).WriteText(w)
+}
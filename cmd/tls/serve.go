@@ -0,0 +1,182 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simondrake/genc/internal/certs"
+	"github.com/simondrake/genc/internal/output"
+)
+
+type serveOptions struct {
+	certificate tls.Certificate
+	alpn        []string
+	clientAuth  clientAuth
+	clientCAs   *x509.CertPool
+}
+
+func newServeCommand() *cobra.Command {
+	var (
+		addr           string
+		certFile       string
+		keyFile        string
+		passphraseFile string
+		clientCAFile   string
+	)
+
+	opts := serveOptions{clientAuth: clientAuthNone}
+	format := output.Text
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "run a local TLS server that logs the details of each handshake",
+		Long: `run a local TLS server that logs the details of each handshake
+
+The SNI, ALPN protocols, versions, cipher suites, signature schemes and curves offered in each client hello are logged, followed by the negotiated parameters and any client certificates once the handshake completes.
+HTTP requests are answered with a JSON description of the connection.
+
+If --cert and --key aren't provided, a short lived self-signed certificate for localhost is used.`,
+		Example: `
+    # Serve a self-signed certificate for localhost
+    $ genc tls serve --addr 127.0.0.1:8443
+
+    # Serve a certificate issued by genc ca, and ask for a client certificate
+    $ genc tls serve --cert server.crt --key server.key --client-auth request
+
+    # Require client certificates issued by genc ca, logging the handshakes as JSON
+    $ genc tls serve --cert server.crt --key server.key --client-auth verify --client-ca ca/root.crt --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+
+			if certFile != "" {
+				opts.certificate, err = loadKeyPair(certFile, keyFile, passphraseFile)
+			} else {
+				fmt.Fprintln(os.Stderr, "using a self-signed certificate for localhost, 127.0.0.1 and ::1")
+				opts.certificate, err = selfSigned()
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error loading certificate: %w", err))
+				os.Exit(1)
+			}
+
+			if clientCAFile != "" {
+				if opts.clientCAs, err = certs.LoadPool(clientCAFile); err != nil {
+					fmt.Fprintln(os.Stderr, fmt.Errorf("error loading client CAs: %w", err))
+					os.Exit(1)
+				}
+			} else if opts.clientAuth == clientAuthVerify {
+				fmt.Fprintln(os.Stderr, errors.New("--client-ca is required to verify client certificates"))
+				os.Exit(1)
+			}
+
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error listening: %w", err))
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stderr, "listening on %s\n", ln.Addr())
+
+			srv := newServer(opts, &eventLog{w: os.Stdout, format: format})
+
+			if err := srv.Serve(tls.NewListener(ln, srv.TLSConfig)); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error serving: %w", err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	serveCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8443", "the address to listen on")
+	serveCmd.Flags().StringVar(&certFile, "cert", "", "the location of the certificate on disk, followed by any intermediates")
+	serveCmd.Flags().StringVar(&keyFile, "key", "", "the location of the private key on disk")
+	serveCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "the location of the private key passphrase on disk, prompted for if not provided")
+	serveCmd.Flags().StringSliceVar(&opts.alpn, "alpn", []string{"h2", "http/1.1"}, "the ALPN protocols to accept, in order of preference")
+	serveCmd.Flags().Var(&opts.clientAuth, "client-auth", "whether to request, require or verify client certificates")
+	serveCmd.Flags().StringVar(&clientCAFile, "client-ca", "", "the location of a bundle of CA certificates to verify client certificates against")
+	serveCmd.Flags().Var(&format, "output", "the output format")
+
+	serveCmd.MarkFlagsRequiredTogether("cert", "key")
+
+	return serveCmd
+}
+
+// eventLog writes handshake events, which can be logged concurrently.
+type eventLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format output.Format
+}
+
+func (l *eventLog) write(v interface{ WriteText(io.Writer) error }) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+
+	if l.format == output.JSON {
+		err = output.WriteJSON(l.w, v)
+	} else {
+		err = v.WriteText(l.w)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("error writing output: %w", err))
+	}
+}
+
+// newServer returns an HTTP server that logs each client hello and
+// completed handshake to l. It must be served on a listener using its
+// TLSConfig.
+func newServer(opts serveOptions, l *eventLog) *http.Server {
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{opts.certificate},
+		NextProtos:   opts.alpn,
+		ClientAuth:   opts.clientAuth.tlsClientAuth(),
+		ClientCAs:    opts.clientCAs,
+		MinVersion:   tls.VersionTLS10,
+	}
+
+	cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		l.write(describeHello(hello, time.Now()))
+
+		remote := hello.Conn.RemoteAddr().String()
+
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		c.VerifyConnection = func(cs tls.ConnectionState) error {
+			d := describeConnection(remote, cs, time.Now())
+			d.Event = "handshake"
+
+			l.write(d)
+
+			return nil
+		}
+
+		return c, nil
+	}
+
+	return &http.Server{
+		TLSConfig:         cfg,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          log.New(os.Stderr, "", log.LstdFlags),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			if err := output.WriteJSON(w, describeConnection(r.RemoteAddr, *r.TLS, time.Now())); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("error writing response: %w", err))
+			}
+		}),
+	}
+}
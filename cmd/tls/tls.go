@@ -0,0 +1,15 @@
+package tls
+
+import "github.com/spf13/cobra"
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tls",
+		Short: "TLS handshake debugging commands",
+	}
+
+	cmd.AddCommand(newServeCommand())
+	cmd.AddCommand(newProbeCommand())
+
+	return cmd
+}
@@ -0,0 +1,132 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simondrake/genc/internal/output"
+)
+
+func TestServeAndProbe(t *testing.T) {
+	serverCert, err := selfSigned()
+	if err != nil {
+		t.Fatalf("selfSigned returned an error when one wasn't expected: %+v", err)
+	}
+
+	clientCert, err := selfSigned()
+	if err != nil {
+		t.Fatalf("selfSigned returned an error when one wasn't expected: %+v", err)
+	}
+
+	var buf bytes.Buffer
+
+	l := &eventLog{w: &buf, format: output.Text}
+	srv := newServer(serveOptions{certificate: serverCert, alpn: []string{"h2", "http/1.1"}, clientAuth: clientAuthRequest}, l)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned an error when one wasn't expected: %+v", err)
+	}
+
+	go srv.Serve(tls.NewListener(ln, srv.TLSConfig)) //nolint:errcheck // Serve always returns an error once closed
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.Leaf)
+
+	t.Run("Valid", func(t *testing.T) {
+		r, err := probe(ln.Addr().String(), probeOptions{
+			serverName:  "localhost",
+			alpn:        []string{"http/1.1"},
+			roots:       roots,
+			certificate: &clientCert,
+			timeout:     5 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("probe returned an error when one wasn't expected: %+v", err)
+		}
+
+		if !r.Verification.Valid {
+			t.Errorf("expected the chain to be valid, got %+v", r.Verification)
+		}
+
+		if r.Alert != "" {
+			t.Errorf("expected no alert, got %q", r.Alert)
+		}
+
+		if r.Connection.ALPN != "http/1.1" || r.Connection.Version != "TLS 1.3" || len(r.Connection.Certificates) != 1 {
+			t.Errorf("unexpected connection details %+v", r.Connection)
+		}
+
+		// The server logs the handshake once it has received the client's
+		// Finished message, which can be after the client returns
+		var log string
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			l.mu.Lock()
+			log = buf.String()
+			l.mu.Unlock()
+
+			if strings.Contains(log, "Handshake with") {
+				break
+			}
+		}
+
+		for _, want := range []string{"Client Hello from", "Server Name:            localhost", "ALPN:                   http/1.1", "Client Certificates:    1", "CN=localhost"} {
+			if !strings.Contains(log, want) {
+				t.Errorf("expected the log to contain '%s', got:\n%s", want, log)
+			}
+		}
+	})
+
+	t.Run("WrongHostname", func(t *testing.T) {
+		r, err := probe(ln.Addr().String(), probeOptions{serverName: "example.com", roots: roots, timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatalf("probe returned an error when one wasn't expected: %+v", err)
+		}
+
+		if r.Verification.Valid {
+			t.Errorf("expected the chain to be invalid for example.com")
+		}
+	})
+
+	t.Run("ClientCertificateRejected", func(t *testing.T) {
+		// Only the server's certificate is trusted for client certificates,
+		// so the client certificate is rejected once the handshake completes
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(serverCert.Leaf)
+
+		srv := newServer(serveOptions{certificate: serverCert, clientAuth: clientAuthVerify, clientCAs: clientCAs}, &eventLog{w: &bytes.Buffer{}, format: output.Text})
+		srv.ErrorLog = log.New(io.Discard, "", 0)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen returned an error when one wasn't expected: %+v", err)
+		}
+
+		go srv.Serve(tls.NewListener(ln, srv.TLSConfig)) //nolint:errcheck // Serve always returns an error once closed
+		defer srv.Close()
+
+		for _, certificate := range []*tls.Certificate{&clientCert, nil} {
+			r, err := probe(ln.Addr().String(), probeOptions{serverName: "localhost", roots: roots, certificate: certificate, timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("probe returned an error when one wasn't expected: %+v", err)
+			}
+
+			if r.Connection.Version != "TLS 1.3" {
+				t.Errorf("expected TLS 1.3, got %s", r.Connection.Version)
+			}
+
+			if !strings.Contains(r.Alert, "tls:") {
+				t.Errorf("expected the rejection to be reported as an alert, got %q", r.Alert)
+			}
+		}
+	})
+}
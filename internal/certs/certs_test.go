@@ -0,0 +1,120 @@
+package certs
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+	"time"
+)
+
+func TestParseSubject(t *testing.T) {
+	for _, s := range []string{
+		"/C=GB/O=Wibble Wobble/CN=example.com",
+		"CN=example.com, O=Wibble Wobble, C=GB",
+	} {
+		n, err := ParseSubject(s)
+		if err != nil {
+			t.Fatalf("ParseSubject returned an error when one wasn't expected: %+v", err)
+		}
+
+		if n.CommonName != "example.com" || n.Organization[0] != "Wibble Wobble" || n.Country[0] != "GB" {
+			t.Errorf("unexpected name %q parsed from %q", n, s)
+		}
+	}
+
+	if _, err := ParseSubject("/X=wibble"); err == nil {
+		t.Errorf("expected an error for an unsupported attribute")
+	}
+}
+
+func TestParseSANs(t *testing.T) {
+	s, err := ParseSANs([]string{
+		"example.com",
+		"DNS:www.example.com",
+		"10.0.0.1",
+		"IP:::1",
+		"wibble@example.com",
+		"URI:spiffe://example.com/wibble",
+		"https://example.com",
+	})
+	if err != nil {
+		t.Fatalf("ParseSANs returned an error when one wasn't expected: %+v", err)
+	}
+
+	if len(s.DNSNames) != 2 || len(s.IPAddresses) != 2 || len(s.EmailAddresses) != 1 || len(s.URIs) != 2 {
+		t.Errorf("unexpected subject alternative names %+v", s)
+	}
+
+	if s.URIs[0].String() != "spiffe://example.com/wibble" {
+		t.Errorf("expected the URI: prefix to be removed, got %q", s.URIs[0])
+	}
+
+	if _, err := ParseSANs([]string{"IP:wibble"}); err == nil {
+		t.Errorf("expected an error for an invalid ip address")
+	}
+}
+
+func TestKeyUsageExtension(t *testing.T) {
+	ku, err := ParseKeyUsage([]string{"digitalSignature", "keyEncipherment"})
+	if err != nil {
+		t.Fatalf("ParseKeyUsage returned an error when one wasn't expected: %+v", err)
+	}
+
+	ext, err := KeyUsageExtension(ku)
+	if err != nil {
+		t.Fatalf("KeyUsageExtension returned an error when one wasn't expected: %+v", err)
+	}
+
+	eku, err := ParseExtKeyUsage([]string{"serverAuth"})
+	if err != nil {
+		t.Fatalf("ParseExtKeyUsage returned an error when one wasn't expected: %+v", err)
+	}
+
+	eext, err := ExtKeyUsageExtension(eku)
+	if err != nil {
+		t.Fatalf("ExtKeyUsageExtension returned an error when one wasn't expected: %+v", err)
+	}
+
+	// Round trip the extensions through a certificate, to make sure they're
+	// encoded the same way as crypto/x509 would encode them
+	tmpl, err := NewTemplate(pkix.Name{CommonName: "wibble"}, time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	tmpl.ExtraExtensions = []pkix.Extension{ext, eext}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		t.Fatalf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	if cert.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment {
+		t.Errorf("unexpected key usage %v", KeyUsageNames(cert.KeyUsage))
+	}
+
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("unexpected extended key usage %v", cert.ExtKeyUsage)
+	}
+
+	if _, err := ParseKeyUsage([]string{"wibble"}); err == nil {
+		t.Errorf("expected an error for an unsupported key usage")
+	}
+
+	if !ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 15}) || !ext.Critical {
+		t.Errorf("expected a critical key usage extension, got %v", ext)
+	}
+}
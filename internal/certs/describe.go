@@ -0,0 +1,484 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Details describes the contents of a Bundle.
+type Details struct {
+	Certificates []CertificateDetails `json:"certificates,omitempty"`
+	Requests     []RequestDetails     `json:"requests,omitempty"`
+	CRLs         []CRLDetails         `json:"crls,omitempty"`
+}
+
+type CertificateDetails struct {
+	Subject               string       `json:"subject"`
+	Issuer                string       `json:"issuer"`
+	SerialNumber          string       `json:"serialNumber"`
+	Version               int          `json:"version"`
+	NotBefore             time.Time    `json:"notBefore"`
+	NotAfter              time.Time    `json:"notAfter"`
+	Expiry                string       `json:"expiry"`
+	DaysRemaining         int          `json:"daysRemaining"`
+	Expired               bool         `json:"expired"`
+	SignatureAlgorithm    string       `json:"signatureAlgorithm"`
+	PublicKey             string       `json:"publicKey"`
+	IsCA                  bool         `json:"isCA"`
+	SubjectAltNames       []string     `json:"subjectAltNames,omitempty"`
+	KeyUsage              []string     `json:"keyUsage,omitempty"`
+	ExtKeyUsage           []string     `json:"extKeyUsage,omitempty"`
+	SubjectKeyID          string       `json:"subjectKeyId,omitempty"`
+	AuthorityKeyID        string       `json:"authorityKeyId,omitempty"`
+	OCSPServers           []string     `json:"ocspServers,omitempty"`
+	IssuingCertificateURL []string     `json:"issuingCertificateUrl,omitempty"`
+	CRLDistributionPoints []string     `json:"crlDistributionPoints,omitempty"`
+	Extensions            []Extension  `json:"extensions,omitempty"`
+	Fingerprints          Fingerprints `json:"fingerprints"`
+	SPKIPin               string       `json:"spkiPin"`
+}
+
+type RequestDetails struct {
+	Subject            string      `json:"subject"`
+	SignatureAlgorithm string      `json:"signatureAlgorithm"`
+	SignatureValid     bool        `json:"signatureValid"`
+	PublicKey          string      `json:"publicKey"`
+	SubjectAltNames    []string    `json:"subjectAltNames,omitempty"`
+	Extensions         []Extension `json:"extensions,omitempty"`
+	SPKIPin            string      `json:"spkiPin"`
+}
+
+type CRLDetails struct {
+	Issuer             string           `json:"issuer"`
+	Number             string           `json:"number,omitempty"`
+	ThisUpdate         time.Time        `json:"thisUpdate"`
+	NextUpdate         time.Time        `json:"nextUpdate"`
+	SignatureAlgorithm string           `json:"signatureAlgorithm"`
+	AuthorityKeyID     string           `json:"authorityKeyId,omitempty"`
+	Revoked            []RevokedDetails `json:"revoked,omitempty"`
+}
+
+type RevokedDetails struct {
+	SerialNumber   string    `json:"serialNumber"`
+	RevocationTime time.Time `json:"revocationTime"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+type Extension struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+}
+
+type Fingerprints struct {
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+}
+
+// extensionNames are the names of common X.509 extensions.
+var extensionNames = map[string]string{
+	"2.5.29.14":               "subjectKeyIdentifier",
+	"2.5.29.15":               "keyUsage",
+	"2.5.29.17":               "subjectAltName",
+	"2.5.29.18":               "issuerAltName",
+	"2.5.29.19":               "basicConstraints",
+	"2.5.29.20":               "cRLNumber",
+	"2.5.29.21":               "cRLReason",
+	"2.5.29.30":               "nameConstraints",
+	"2.5.29.31":               "cRLDistributionPoints",
+	"2.5.29.32":               "certificatePolicies",
+	"2.5.29.35":               "authorityKeyIdentifier",
+	"2.5.29.36":               "policyConstraints",
+	"2.5.29.37":               "extKeyUsage",
+	"2.5.29.54":               "inhibitAnyPolicy",
+	"1.3.6.1.5.5.7.1.1":       "authorityInfoAccess",
+	"1.3.6.1.5.5.7.1.24":      "tlsFeature",
+	"1.3.6.1.5.5.7.48.1.5":    "ocspNoCheck",
+	"1.3.6.1.4.1.11129.2.4.2": "signedCertificateTimestampList",
+	"1.3.6.1.4.1.11129.2.4.3": "precertificatePoison",
+}
+
+// revocationReasons are the CRL reason codes from RFC 5280.
+var revocationReasons = map[int]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+// Describe returns the details of every object in b, with expiry calculated
+// relative to now.
+func Describe(b *Bundle, now time.Time) *Details {
+	d := &Details{}
+
+	for _, c := range b.Certificates {
+		d.Certificates = append(d.Certificates, DescribeCertificate(c, now))
+	}
+
+	for _, r := range b.Requests {
+		d.Requests = append(d.Requests, DescribeRequest(r))
+	}
+
+	for _, rl := range b.CRLs {
+		d.CRLs = append(d.CRLs, DescribeCRL(rl))
+	}
+
+	return d
+}
+
+// DescribeCertificate returns the details of c, with expiry calculated
+// relative to now.
+func DescribeCertificate(c *x509.Certificate, now time.Time) CertificateDetails {
+	d := CertificateDetails{
+		Subject:               c.Subject.String(),
+		Issuer:                c.Issuer.String(),
+		SerialNumber:          c.SerialNumber.Text(16),
+		Version:               c.Version,
+		NotBefore:             c.NotBefore,
+		NotAfter:              c.NotAfter,
+		Expiry:                Countdown(c.NotAfter, now),
+		DaysRemaining:         DaysRemaining(c.NotAfter, now),
+		Expired:               now.After(c.NotAfter),
+		SignatureAlgorithm:    c.SignatureAlgorithm.String(),
+		PublicKey:             PublicKeyDescription(c.PublicKey),
+		IsCA:                  c.IsCA,
+		SubjectAltNames:       subjectAltNames(c.DNSNames, c.IPAddresses, c.EmailAddresses, c.URIs),
+		KeyUsage:              KeyUsageNames(c.KeyUsage),
+		OCSPServers:           c.OCSPServer,
+		IssuingCertificateURL: c.IssuingCertificateURL,
+		CRLDistributionPoints: c.CRLDistributionPoints,
+		Extensions:            extensions(c.Extensions),
+		Fingerprints:          Fingerprint(c.Raw),
+		SPKIPin:               SPKIPin(c.RawSubjectPublicKeyInfo),
+	}
+
+	for _, eku := range c.ExtKeyUsage {
+		d.ExtKeyUsage = append(d.ExtKeyUsage, ExtKeyUsageName(eku))
+	}
+
+	for _, oid := range c.UnknownExtKeyUsage {
+		d.ExtKeyUsage = append(d.ExtKeyUsage, oid.String())
+	}
+
+	if len(c.SubjectKeyId) > 0 {
+		d.SubjectKeyID = colonHex(c.SubjectKeyId)
+	}
+
+	if len(c.AuthorityKeyId) > 0 {
+		d.AuthorityKeyID = colonHex(c.AuthorityKeyId)
+	}
+
+	return d
+}
+
+// DescribeRequest returns the details of r.
+func DescribeRequest(r *x509.CertificateRequest) RequestDetails {
+	return RequestDetails{
+		Subject:            r.Subject.String(),
+		SignatureAlgorithm: r.SignatureAlgorithm.String(),
+		SignatureValid:     r.CheckSignature() == nil,
+		PublicKey:          PublicKeyDescription(r.PublicKey),
+		SubjectAltNames:    subjectAltNames(r.DNSNames, r.IPAddresses, r.EmailAddresses, r.URIs),
+		Extensions:         extensions(r.Extensions),
+		SPKIPin:            SPKIPin(r.RawSubjectPublicKeyInfo),
+	}
+}
+
+// DescribeCRL returns the details of rl.
+func DescribeCRL(rl *x509.RevocationList) CRLDetails {
+	d := CRLDetails{
+		Issuer:             rl.Issuer.String(),
+		ThisUpdate:         rl.ThisUpdate,
+		NextUpdate:         rl.NextUpdate,
+		SignatureAlgorithm: rl.SignatureAlgorithm.String(),
+	}
+
+	if rl.Number != nil {
+		d.Number = rl.Number.String()
+	}
+
+	if len(rl.AuthorityKeyId) > 0 {
+		d.AuthorityKeyID = colonHex(rl.AuthorityKeyId)
+	}
+
+	for _, e := range rl.RevokedCertificateEntries {
+		rd := RevokedDetails{
+			SerialNumber:   e.SerialNumber.Text(16),
+			RevocationTime: e.RevocationTime,
+		}
+
+		if e.ReasonCode != 0 {
+			rd.Reason = RevocationReasonName(e.ReasonCode)
+		}
+
+		d.Revoked = append(d.Revoked, rd)
+	}
+
+	return d
+}
+
+// RevocationReasonName returns the RFC 5280 name of a CRL reason code.
+func RevocationReasonName(code int) string {
+	if n, ok := revocationReasons[code]; ok {
+		return n
+	}
+
+	return fmt.Sprintf("unknown (%d)", code)
+}
+
+// Fingerprint returns the SHA-1 and SHA-256 fingerprints of a DER encoded
+// certificate, formatted as colon separated hex.
+func Fingerprint(der []byte) Fingerprints {
+	s1 := sha1.Sum(der)
+	s256 := sha256.Sum256(der)
+
+	return Fingerprints{SHA1: colonHex(s1[:]), SHA256: colonHex(s256[:])}
+}
+
+// SPKIPin returns the base64 encoded SHA-256 hash of a DER encoded subject
+// public key info, as used by HTTP public key pinning (pin-sha256).
+func SPKIPin(spki []byte) string {
+	s := sha256.Sum256(spki)
+
+	return base64.StdEncoding.EncodeToString(s[:])
+}
+
+// PublicKeyDescription returns the algorithm and size of pub, e.g. RSA 2048
+// bit or ECDSA P-256.
+func PublicKeyDescription(pub interface{}) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bit", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+
+	return fmt.Sprintf("unknown (%T)", pub)
+}
+
+// DaysRemaining returns the number of whole days from now until t, which is
+// negative once t has passed.
+func DaysRemaining(t, now time.Time) int {
+	return int(math.Floor(t.Sub(now).Hours() / 24))
+}
+
+// Countdown describes how long it is until t, or how long ago it was.
+func Countdown(t, now time.Time) string {
+	d := t.Sub(now)
+	if d < 0 {
+		return "expired " + duration(-d) + " ago"
+	}
+
+	return "expires in " + duration(d)
+}
+
+func duration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+
+	if days > 0 {
+		return fmt.Sprintf("%s, %s", plural(days, "day"), plural(hours, "hour"))
+	}
+
+	return fmt.Sprintf("%s, %s", plural(hours, "hour"), plural(int(d.Minutes())%60, "minute"))
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+func subjectAltNames(dns []string, ips []net.IP, emails []string, uris []*url.URL) []string {
+	var names []string
+
+	for _, n := range dns {
+		names = append(names, "DNS:"+n)
+	}
+
+	for _, ip := range ips {
+		names = append(names, "IP:"+ip.String())
+	}
+
+	for _, e := range emails {
+		names = append(names, "EMAIL:"+e)
+	}
+
+	for _, u := range uris {
+		names = append(names, "URI:"+u.String())
+	}
+
+	return names
+}
+
+func extensions(exts []pkix.Extension) []Extension {
+	var e []Extension
+
+	for _, ext := range exts {
+		e = append(e, Extension{Name: extensionName(ext.Id), Critical: ext.Critical})
+	}
+
+	return e
+}
+
+func extensionName(oid asn1.ObjectIdentifier) string {
+	if n, ok := extensionNames[oid.String()]; ok {
+		return fmt.Sprintf("%s (%s)", n, oid)
+	}
+
+	return oid.String()
+}
+
+func colonHex(b []byte) string {
+	h := strings.ToUpper(hex.EncodeToString(b))
+
+	parts := make([]string, 0, len(b))
+	for i := 0; i < len(h); i += 2 {
+		parts = append(parts, h[i:i+2])
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// WriteText writes d in a human readable form.
+func (d *Details) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	field := func(label string, value interface{}) {
+		if label != "" {
+			label += ":"
+		}
+
+		fmt.Fprintf(&sb, "%-24s%v\n", label, value)
+	}
+
+	list := func(label string, values []string) {
+		if len(values) > 0 {
+			field(label, strings.Join(values, ", "))
+		}
+	}
+
+	heading := func(h string) {
+		if sb.Len() > 0 {
+			fmt.Fprintln(&sb)
+		}
+
+		fmt.Fprintln(&sb, h)
+		fmt.Fprintln(&sb, "------------------------")
+	}
+
+	exts := func(e []Extension) {
+		for n, ext := range e {
+			label := ""
+			if n == 0 {
+				label = "Extensions"
+			}
+
+			v := ext.Name
+			if ext.Critical {
+				v += " [critical]"
+			}
+
+			field(label, v)
+		}
+	}
+
+	for n, c := range d.Certificates {
+		heading(fmt.Sprintf("Certificate %d", n+1))
+		field("Subject", c.Subject)
+		field("Issuer", c.Issuer)
+		field("Serial", c.SerialNumber)
+		field("Version", c.Version)
+		field("Not Before", c.NotBefore.Format(time.RFC3339))
+		field("Not After", fmt.Sprintf("%s (%s)", c.NotAfter.Format(time.RFC3339), c.Expiry))
+		field("Signature Algorithm", c.SignatureAlgorithm)
+		field("Public Key", c.PublicKey)
+		field("CA", c.IsCA)
+		list("Subject Alt Names", c.SubjectAltNames)
+		list("Key Usage", c.KeyUsage)
+		list("Extended Key Usage", c.ExtKeyUsage)
+
+		if c.SubjectKeyID != "" {
+			field("Subject Key ID", c.SubjectKeyID)
+		}
+
+		if c.AuthorityKeyID != "" {
+			field("Authority Key ID", c.AuthorityKeyID)
+		}
+
+		list("OCSP Servers", c.OCSPServers)
+		list("Issuing Certificate URL", c.IssuingCertificateURL)
+		list("CRL Distribution Points", c.CRLDistributionPoints)
+		exts(c.Extensions)
+		field("SHA-1 Fingerprint", c.Fingerprints.SHA1)
+		field("SHA-256 Fingerprint", c.Fingerprints.SHA256)
+		field("SPKI Pin (SHA-256)", c.SPKIPin)
+	}
+
+	for n, r := range d.Requests {
+		heading(fmt.Sprintf("Certificate Request %d", n+1))
+		field("Subject", r.Subject)
+		field("Signature Algorithm", r.SignatureAlgorithm)
+		field("Signature Valid", r.SignatureValid)
+		field("Public Key", r.PublicKey)
+		list("Subject Alt Names", r.SubjectAltNames)
+		exts(r.Extensions)
+		field("SPKI Pin (SHA-256)", r.SPKIPin)
+	}
+
+	for n, rl := range d.CRLs {
+		heading(fmt.Sprintf("CRL %d", n+1))
+		field("Issuer", rl.Issuer)
+
+		if rl.Number != "" {
+			field("Number", rl.Number)
+		}
+
+		field("This Update", rl.ThisUpdate.Format(time.RFC3339))
+		field("Next Update", rl.NextUpdate.Format(time.RFC3339))
+		field("Signature Algorithm", rl.SignatureAlgorithm)
+
+		if rl.AuthorityKeyID != "" {
+			field("Authority Key ID", rl.AuthorityKeyID)
+		}
+
+		field("Revoked", len(rl.Revoked))
+
+		for _, r := range rl.Revoked {
+			v := fmt.Sprintf("%s at %s", r.SerialNumber, r.RevocationTime.Format(time.RFC3339))
+			if r.Reason != "" {
+				v += " (" + r.Reason + ")"
+			}
+
+			field("", v)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
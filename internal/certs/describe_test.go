@@ -0,0 +1,143 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+
+	tmpl, err := NewTemplate(pkix.Name{CommonName: "example.com"}, now.Add(10*24*time.Hour+time.Hour))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	tmpl.IsCA = true
+	tmpl.DNSNames = []string{"example.com"}
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "wibble"}}, key)
+	if err != nil {
+		t.Fatalf("CreateCertificateRequest returned an error when one wasn't expected: %+v", err)
+	}
+
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now,
+		NextUpdate: now.AddDate(0, 0, 7),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(10), RevocationTime: now, ReasonCode: 1},
+		},
+	}, cert, key)
+	if err != nil {
+		t.Fatalf("CreateRevocationList returned an error when one wasn't expected: %+v", err)
+	}
+
+	bundle := strings.Join([]string{
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDER})),
+	}, "")
+
+	t.Run("PEM", func(t *testing.T) {
+		b, err := Parse([]byte(bundle))
+		if err != nil {
+			t.Fatalf("Parse returned an error when one wasn't expected: %+v", err)
+		}
+
+		d := Describe(b, now)
+
+		if len(d.Certificates) != 1 || len(d.Requests) != 1 || len(d.CRLs) != 1 {
+			t.Fatalf("expected one of each object, got %+v", d)
+		}
+
+		c := d.Certificates[0]
+
+		if c.DaysRemaining != 10 || c.Expired || c.Expiry != "expires in 10 days, 1 hour" {
+			t.Errorf("unexpected expiry %d %v %q", c.DaysRemaining, c.Expired, c.Expiry)
+		}
+
+		if c.PublicKey != "ECDSA P-256" || !c.IsCA || len(c.SubjectAltNames) != 1 || c.SubjectAltNames[0] != "DNS:example.com" {
+			t.Errorf("unexpected certificate details %+v", c)
+		}
+
+		spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		if c.SPKIPin != base64.StdEncoding.EncodeToString(spki[:]) {
+			t.Errorf("unexpected spki pin %q", c.SPKIPin)
+		}
+
+		if len(c.Fingerprints.SHA256) != 95 || len(c.Fingerprints.SHA1) != 59 {
+			t.Errorf("unexpected fingerprints %+v", c.Fingerprints)
+		}
+
+		if !d.Requests[0].SignatureValid {
+			t.Errorf("expected the csr signature to be valid")
+		}
+
+		if rl := d.CRLs[0]; len(rl.Revoked) != 1 || rl.Revoked[0].Reason != "keyCompromise" || rl.Number != "1" {
+			t.Errorf("unexpected crl details %+v", rl)
+		}
+
+		var sb strings.Builder
+		if err := d.WriteText(&sb); err != nil {
+			t.Fatalf("WriteText returned an error when one wasn't expected: %+v", err)
+		}
+
+		for _, s := range []string{"Certificate 1", "Certificate Request 1", "CRL 1", "SPKI Pin (SHA-256):"} {
+			if !strings.Contains(sb.String(), s) {
+				t.Errorf("expected text output to contain %q", s)
+			}
+		}
+	})
+
+	t.Run("DER", func(t *testing.T) {
+		for _, der := range [][]byte{certDER, csrDER, crlDER} {
+			b, err := Parse(der)
+			if err != nil {
+				t.Fatalf("Parse returned an error when one wasn't expected: %+v", err)
+			}
+
+			if len(b.Certificates)+len(b.Requests)+len(b.CRLs) != 1 {
+				t.Errorf("expected a single object, got %+v", b)
+			}
+		}
+
+		if _, err := Parse([]byte("wibble")); err == nil {
+			t.Errorf("expected an error parsing invalid input")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		c := DescribeCertificate(cert, now.AddDate(0, 0, 12))
+
+		if !c.Expired || c.DaysRemaining != -2 || !strings.HasPrefix(c.Expiry, "expired 1 day") {
+			t.Errorf("unexpected expiry %d %v %q", c.DaysRemaining, c.Expired, c.Expiry)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// Bundle holds the certificates, CSRs and CRLs found in a file.
+type Bundle struct {
+	Certificates []*x509.Certificate
+	Requests     []*x509.CertificateRequest
+	CRLs         []*x509.RevocationList
+}
+
+func (b *Bundle) empty() bool {
+	return len(b.Certificates)+len(b.Requests)+len(b.CRLs) == 0
+}
+
+// Parse decodes every certificate, CSR and CRL in data, which can either be
+// a PEM bundle or a single DER encoded object. PKCS#7 certificate bundles
+// (.p7b/.p7c) are supported in both encodings.
+func Parse(data []byte) (*Bundle, error) {
+	b := &Bundle{}
+
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		if err := b.addDER(data); err != nil {
+			return nil, err
+		}
+
+		return b, nil
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if err := b.addPEM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.empty() {
+		return nil, errors.New("no certificates, CSRs or CRLs were found")
+	}
+
+	return b, nil
+}
+
+func (b *Bundle) addPEM(block *pem.Block) error {
+	switch block.Type {
+	case "CERTIFICATE", "TRUSTED CERTIFICATE":
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate: %w", err)
+		}
+
+		b.Certificates = append(b.Certificates, c)
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		r, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("error parsing csr: %w", err)
+		}
+
+		b.Requests = append(b.Requests, r)
+	case "X509 CRL":
+		rl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("error parsing crl: %w", err)
+		}
+
+		b.CRLs = append(b.CRLs, rl)
+	case "PKCS7", "CMS":
+		return b.addPKCS7(block.Bytes)
+	}
+
+	// Other blocks, such as private keys, are ignored
+	return nil
+}
+
+func (b *Bundle) addDER(der []byte) error {
+	if c, err := x509.ParseCertificate(der); err == nil {
+		b.Certificates = append(b.Certificates, c)
+		return nil
+	}
+
+	if r, err := x509.ParseCertificateRequest(der); err == nil {
+		b.Requests = append(b.Requests, r)
+		return nil
+	}
+
+	if rl, err := x509.ParseRevocationList(der); err == nil {
+		b.CRLs = append(b.CRLs, rl)
+		return nil
+	}
+
+	if err := b.addPKCS7(der); err == nil && !b.empty() {
+		return nil
+	}
+
+	return errors.New("input is not a PEM bundle, or a DER encoded certificate, CSR, CRL or PKCS#7 bundle")
+}
+
+func (b *Bundle) addPKCS7(der []byte) error {
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return fmt.Errorf("error parsing pkcs7: %w", err)
+	}
+
+	b.Certificates = append(b.Certificates, p7.Certificates...)
+
+	for i := range p7.CRLs {
+		// The CRLs are parsed with the deprecated pkix types, so they're
+		// re-encoded and parsed again
+		der, err := asn1.Marshal(p7.CRLs[i])
+		if err != nil {
+			return err
+		}
+
+		rl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return fmt.Errorf("error parsing crl: %w", err)
+		}
+
+		b.CRLs = append(b.CRLs, rl)
+	}
+
+	return nil
+}
+
+// LoadCertificates returns the certificates in the file at path, in the
+// order they appear.
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bundle.Certificates) == 0 {
+		return nil, errors.New("no certificates were found")
+	}
+
+	return bundle.Certificates, nil
+}
+
+// LoadPool returns a pool of the certificates in the file at path.
+func LoadPool(path string) (*x509.CertPool, error) {
+	cs, err := LoadCertificates(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+
+	for _, c := range cs {
+		pool.AddCert(c)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,174 @@
+package certs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// derExtensions are the file extensions that are parsed when a file doesn't
+// contain any PEM blocks, to avoid reporting every binary file as an error.
+var derExtensions = map[string]bool{
+	".der": true,
+	".cer": true,
+	".crt": true,
+	".p7b": true,
+	".p7c": true,
+}
+
+// ScanReport lists the certificates found by Scan that expire within the
+// window.
+type ScanReport struct {
+	Files        int          `json:"files"`
+	Certificates int          `json:"certificates"`
+	Expiring     []ScanResult `json:"expiring"`
+	Errors       []ScanError  `json:"errors,omitempty"`
+	Before       time.Time    `json:"before"`
+	now          time.Time
+}
+
+type ScanResult struct {
+	Path          string    `json:"path"`
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	SerialNumber  string    `json:"serialNumber"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+	Expired       bool      `json:"expired"`
+}
+
+type ScanError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Scan walks each of the paths, which can be files or directories, and
+// reports every certificate that expires before now plus within. Hidden
+// directories, such as .git, are skipped.
+func Scan(paths []string, within time.Duration, now time.Time) (*ScanReport, error) {
+	r := &ScanReport{Expiring: []ScanResult{}, Before: now.Add(within), now: now}
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			r.scanFile(path)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(r.Expiring, func(i, j int) bool {
+		return r.Expiring[i].NotAfter.Before(r.Expiring[j].NotAfter)
+	})
+
+	return r, nil
+}
+
+func (r *ScanReport) scanFile(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Errors = append(r.Errors, ScanError{Path: path, Error: err.Error()})
+		return
+	}
+
+	isPEM := bytes.Contains(data, []byte("-----BEGIN"))
+	if !isPEM && !derExtensions[strings.ToLower(filepath.Ext(path))] {
+		return
+	}
+
+	b, err := Parse(data)
+	if err != nil {
+		// PEM files that only contain other blocks, such as private keys,
+		// aren't errors
+		if isPEM && !bytes.Contains(data, []byte("-----BEGIN CERTIFICATE")) && !bytes.Contains(data, []byte("-----BEGIN PKCS7")) {
+			return
+		}
+
+		r.Errors = append(r.Errors, ScanError{Path: path, Error: err.Error()})
+
+		return
+	}
+
+	if len(b.Certificates) > 0 {
+		r.Files++
+	}
+
+	for _, c := range b.Certificates {
+		r.Certificates++
+
+		if c.NotAfter.After(r.Before) {
+			continue
+		}
+
+		r.Expiring = append(r.Expiring, ScanResult{
+			Path:          path,
+			Subject:       c.Subject.String(),
+			Issuer:        c.Issuer.String(),
+			SerialNumber:  c.SerialNumber.Text(16),
+			NotAfter:      c.NotAfter,
+			DaysRemaining: DaysRemaining(c.NotAfter, r.now),
+			Expired:       r.now.After(c.NotAfter),
+		})
+	}
+}
+
+// WriteText writes r in a human readable form.
+func (r *ScanReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	if len(r.Expiring) > 0 {
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "NOT AFTER\tDAYS\tSUBJECT\tPATH")
+
+		for _, e := range r.Expiring {
+			days := fmt.Sprint(e.DaysRemaining)
+			if e.Expired {
+				days = "expired"
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.NotAfter.UTC().Format(time.RFC3339), days, e.Subject, e.Path)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		sb.WriteString("\n")
+	}
+
+	for _, e := range r.Errors {
+		fmt.Fprintf(&sb, "error: %s: %s\n", e.Path, e.Error)
+	}
+
+	fmt.Fprintf(&sb, "%d of %d certificates, in %d files, expire before %s\n", len(r.Expiring), r.Certificates, r.Files, r.Before.UTC().Format(time.RFC3339))
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
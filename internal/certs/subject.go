@@ -0,0 +1,121 @@
+package certs
+
+import (
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ParseSubject parses a distinguished name, either in the OpenSSL form
+// (/C=GB/O=Wibble/CN=example.com) or as comma separated attributes
+// (CN=example.com,O=Wibble).
+func ParseSubject(s string) (pkix.Name, error) {
+	var n pkix.Name
+
+	sep := ","
+	if strings.HasPrefix(s, "/") {
+		sep = "/"
+		s = s[1:]
+	}
+
+	for _, attr := range strings.Split(s, sep) {
+		attr = strings.TrimSpace(attr)
+		if attr == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(attr, "=")
+		if !ok {
+			return pkix.Name{}, fmt.Errorf("invalid subject attribute '%s', expected key=value", attr)
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(k)) {
+		case "C":
+			n.Country = append(n.Country, v)
+		case "ST":
+			n.Province = append(n.Province, v)
+		case "L":
+			n.Locality = append(n.Locality, v)
+		case "STREET":
+			n.StreetAddress = append(n.StreetAddress, v)
+		case "POSTALCODE":
+			n.PostalCode = append(n.PostalCode, v)
+		case "O":
+			n.Organization = append(n.Organization, v)
+		case "OU":
+			n.OrganizationalUnit = append(n.OrganizationalUnit, v)
+		case "CN":
+			n.CommonName = v
+		case "SERIALNUMBER":
+			n.SerialNumber = v
+		default:
+			return pkix.Name{}, fmt.Errorf("unsupported subject attribute '%s'", k)
+		}
+	}
+
+	return n, nil
+}
+
+// SANs are the subject alternative names of a certificate or CSR.
+type SANs struct {
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+	URIs           []*url.URL
+}
+
+// ParseSANs sorts subject alternative names by type. A name can be prefixed
+// with its type (DNS:, IP:, EMAIL: or URI:), otherwise the type is detected
+// from its value.
+func ParseSANs(names []string) (SANs, error) {
+	var s SANs
+
+	for _, name := range names {
+		typ, v, ok := strings.Cut(name, ":")
+		if typ = strings.ToUpper(typ); !ok || !sanTypes[typ] {
+			typ, v = detectSANType(name), name
+		}
+
+		switch typ {
+		case "DNS":
+			s.DNSNames = append(s.DNSNames, v)
+		case "IP":
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return SANs{}, fmt.Errorf("invalid ip address '%s'", v)
+			}
+
+			s.IPAddresses = append(s.IPAddresses, ip)
+		case "EMAIL":
+			s.EmailAddresses = append(s.EmailAddresses, v)
+		case "URI":
+			u, err := url.Parse(v)
+			if err != nil {
+				return SANs{}, fmt.Errorf("invalid uri '%s': %w", v, err)
+			}
+
+			s.URIs = append(s.URIs, u)
+		default:
+			return SANs{}, fmt.Errorf("unsupported subject alternative name type '%s'", typ)
+		}
+	}
+
+	return s, nil
+}
+
+var sanTypes = map[string]bool{"DNS": true, "IP": true, "EMAIL": true, "URI": true}
+
+func detectSANType(name string) string {
+	switch {
+	case net.ParseIP(name) != nil:
+		return "IP"
+	case strings.Contains(name, "://"):
+		return "URI"
+	case strings.Contains(name, "@"):
+		return "EMAIL"
+	}
+
+	return "DNS"
+}
@@ -0,0 +1,34 @@
+// Package certs contains the X.509 certificate logic shared by genc commands.
+package certs
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"time"
+)
+
+// SerialNumber returns a random 128 bit serial number.
+func SerialNumber() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	return rand.Int(rand.Reader, limit)
+}
+
+// NewTemplate returns a certificate template for subject, with a random
+// serial number, that is valid from now until notAfter.
+func NewTemplate(subject pkix.Name, notAfter time.Time) (*x509.Certificate, error) {
+	sn, err := SerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	return &x509.Certificate{
+		SerialNumber:          sn,
+		Subject:               subject,
+		NotBefore:             time.Now(),
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+	}, nil
+}
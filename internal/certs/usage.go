@@ -0,0 +1,153 @@
+package certs
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/bits"
+)
+
+var keyUsages = []struct {
+	name  string
+	usage x509.KeyUsage
+}{
+	{"digitalSignature", x509.KeyUsageDigitalSignature},
+	{"contentCommitment", x509.KeyUsageContentCommitment},
+	{"keyEncipherment", x509.KeyUsageKeyEncipherment},
+	{"dataEncipherment", x509.KeyUsageDataEncipherment},
+	{"keyAgreement", x509.KeyUsageKeyAgreement},
+	{"keyCertSign", x509.KeyUsageCertSign},
+	{"cRLSign", x509.KeyUsageCRLSign},
+	{"encipherOnly", x509.KeyUsageEncipherOnly},
+	{"decipherOnly", x509.KeyUsageDecipherOnly},
+}
+
+var extKeyUsages = []struct {
+	name  string
+	usage x509.ExtKeyUsage
+	oid   asn1.ObjectIdentifier
+}{
+	{"any", x509.ExtKeyUsageAny, asn1.ObjectIdentifier{2, 5, 29, 37, 0}},
+	{"serverAuth", x509.ExtKeyUsageServerAuth, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}},
+	{"clientAuth", x509.ExtKeyUsageClientAuth, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}},
+	{"codeSigning", x509.ExtKeyUsageCodeSigning, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}},
+	{"emailProtection", x509.ExtKeyUsageEmailProtection, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}},
+	{"timeStamping", x509.ExtKeyUsageTimeStamping, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}},
+	{"OCSPSigning", x509.ExtKeyUsageOCSPSigning, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 9}},
+}
+
+var (
+	oidExtensionKeyUsage    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// ParseKeyUsage combines the named key usages, such as digitalSignature and
+// keyEncipherment.
+func ParseKeyUsage(names []string) (x509.KeyUsage, error) {
+	var ku x509.KeyUsage
+
+next:
+	for _, n := range names {
+		for _, u := range keyUsages {
+			if u.name == n {
+				ku |= u.usage
+				continue next
+			}
+		}
+
+		return 0, fmt.Errorf("unsupported key usage '%s'", n)
+	}
+
+	return ku, nil
+}
+
+// ParseExtKeyUsage returns the named extended key usages, such as serverAuth
+// and clientAuth.
+func ParseExtKeyUsage(names []string) ([]x509.ExtKeyUsage, error) {
+	var eku []x509.ExtKeyUsage
+
+next:
+	for _, n := range names {
+		for _, u := range extKeyUsages {
+			if u.name == n {
+				eku = append(eku, u.usage)
+				continue next
+			}
+		}
+
+		return nil, fmt.Errorf("unsupported extended key usage '%s'", n)
+	}
+
+	return eku, nil
+}
+
+// KeyUsageNames returns the names of the key usages set in ku.
+func KeyUsageNames(ku x509.KeyUsage) []string {
+	var names []string
+
+	for _, u := range keyUsages {
+		if ku&u.usage != 0 {
+			names = append(names, u.name)
+		}
+	}
+
+	return names
+}
+
+// ExtKeyUsageName returns the name of eku.
+func ExtKeyUsageName(eku x509.ExtKeyUsage) string {
+	for _, u := range extKeyUsages {
+		if u.usage == eku {
+			return u.name
+		}
+	}
+
+	return fmt.Sprintf("unknown (%d)", eku)
+}
+
+// KeyUsageExtension encodes ku as a key usage extension, as used when
+// requesting extensions in a CSR.
+func KeyUsageExtension(ku x509.KeyUsage) (pkix.Extension, error) {
+	b := []byte{bits.Reverse8(byte(ku)), bits.Reverse8(byte(ku >> 8))}
+	if b[1] == 0 {
+		b = b[:1]
+	}
+
+	bitLength := len(b) * 8
+	if last := b[len(b)-1]; last != 0 {
+		bitLength -= bits.TrailingZeros8(last)
+	}
+
+	v, err := asn1.Marshal(asn1.BitString{Bytes: b, BitLength: bitLength})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: v}, nil
+}
+
+// ExtKeyUsageExtension encodes eku as an extended key usage extension, as
+// used when requesting extensions in a CSR.
+func ExtKeyUsageExtension(eku []x509.ExtKeyUsage) (pkix.Extension, error) {
+	oids := make([]asn1.ObjectIdentifier, len(eku))
+
+	for i, e := range eku {
+		for _, u := range extKeyUsages {
+			if u.usage == e {
+				oids[i] = u.oid
+			}
+		}
+
+		if oids[i] == nil {
+			return pkix.Extension{}, fmt.Errorf("unsupported extended key usage '%d'", e)
+		}
+	}
+
+	v, err := asn1.Marshal(oids)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidExtensionExtKeyUsage, Value: v}, nil
+}
@@ -0,0 +1,210 @@
+package certs
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// VerifyOptions are the parameters of Verify.
+type VerifyOptions struct {
+	// Roots are the trusted roots, the system roots are used if nil
+	Roots         *x509.CertPool
+	Intermediates []*x509.Certificate
+	Time          time.Time
+	// Hostname is only checked if it's set
+	Hostname string
+	// ExtKeyUsages are only checked if they're set, any of them are accepted
+	ExtKeyUsages []x509.ExtKeyUsage
+}
+
+// Verification is the result of Verify. Each check is made independently, so
+// that every reason a certificate is invalid is reported.
+type Verification struct {
+	Valid  bool     `json:"valid"`
+	Chain  []string `json:"chain,omitempty"`
+	Checks []Check  `json:"checks"`
+}
+
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (v *Verification) add(name string, err error) {
+	c := Check{Name: name, OK: err == nil}
+	if err != nil {
+		c.Reason = strings.TrimPrefix(err.Error(), "x509: ")
+	}
+
+	v.Checks = append(v.Checks, c)
+	v.Valid = v.Valid && c.OK
+}
+
+// Verify builds a chain from leaf to one of the roots, and checks the
+// validity period, hostname and extended key usage.
+func Verify(leaf *x509.Certificate, opts VerifyOptions) *Verification {
+	v := &Verification{Valid: true}
+
+	if opts.Time.IsZero() {
+		opts.Time = time.Now()
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range opts.Intermediates {
+		intermediates.AddCert(c)
+	}
+
+	vo := x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   opts.Time,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	// An expired certificate stops the chain from being built, so the chain
+	// is built at a time when the certificates are valid, and the validity
+	// is checked separately
+	if validityErr(append([]*x509.Certificate{leaf}, opts.Intermediates...), opts.Time) != nil {
+		vo.CurrentTime = validTime(leaf, opts.Intermediates)
+	}
+
+	chains, err := leaf.Verify(vo)
+	v.add("chain", err)
+
+	chain := []*x509.Certificate{leaf}
+	if err == nil {
+		chain = chains[0]
+
+		for _, c := range chain {
+			v.Chain = append(v.Chain, c.Subject.String())
+		}
+	}
+
+	v.add("validity", validityErr(chain, opts.Time))
+
+	if opts.Hostname != "" {
+		v.add("hostname", leaf.VerifyHostname(opts.Hostname))
+	}
+
+	if len(opts.ExtKeyUsages) > 0 {
+		// Extended key usages are nested, so the whole chain is checked if
+		// it could be built
+		if err == nil {
+			vo.KeyUsages = opts.ExtKeyUsages
+			_, err = leaf.Verify(vo)
+		} else {
+			err = extKeyUsageErr(leaf, opts.ExtKeyUsages)
+		}
+
+		v.add("extKeyUsage", err)
+	}
+
+	return v
+}
+
+func validityErr(chain []*x509.Certificate, t time.Time) error {
+	var reasons []string
+
+	for _, c := range chain {
+		switch {
+		case t.Before(c.NotBefore):
+			reasons = append(reasons, fmt.Sprintf("'%s' is not valid until %s", c.Subject, c.NotBefore.Format(time.RFC3339)))
+		case t.After(c.NotAfter):
+			reasons = append(reasons, fmt.Sprintf("'%s' %s (%s)", c.Subject, Countdown(c.NotAfter, t), c.NotAfter.Format(time.RFC3339)))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return fmt.Errorf("%s", strings.Join(reasons, "; "))
+	}
+
+	return nil
+}
+
+// validTime returns a time when leaf and the intermediates are all valid, if
+// there is one, and the middle of the leaf's validity period otherwise.
+func validTime(leaf *x509.Certificate, intermediates []*x509.Certificate) time.Time {
+	start, end := leaf.NotBefore, leaf.NotAfter
+
+	for _, c := range intermediates {
+		if c.NotBefore.After(start) {
+			start = c.NotBefore
+		}
+
+		if c.NotAfter.Before(end) {
+			end = c.NotAfter
+		}
+	}
+
+	if !start.Before(end) {
+		start, end = leaf.NotBefore, leaf.NotAfter
+	}
+
+	return start.Add(end.Sub(start) / 2)
+}
+
+func extKeyUsageErr(leaf *x509.Certificate, usages []x509.ExtKeyUsage) error {
+	// A certificate without extended key usages can be used for anything
+	if len(leaf.ExtKeyUsage) == 0 && len(leaf.UnknownExtKeyUsage) == 0 {
+		return nil
+	}
+
+	for _, have := range leaf.ExtKeyUsage {
+		if have == x509.ExtKeyUsageAny {
+			return nil
+		}
+
+		for _, want := range usages {
+			if have == want {
+				return nil
+			}
+		}
+	}
+
+	var names []string
+	for _, u := range usages {
+		names = append(names, ExtKeyUsageName(u))
+	}
+
+	return fmt.Errorf("certificate is not valid for %s", strings.Join(names, " or "))
+}
+
+var checkLabels = map[string]string{
+	"chain":       "Chain",
+	"validity":    "Validity",
+	"hostname":    "Hostname",
+	"extKeyUsage": "Extended Key Usage",
+}
+
+// WriteText writes v in a human readable form.
+func (v *Verification) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	if len(v.Chain) > 0 {
+		fmt.Fprintf(&sb, "%-24s%s\n", "Path:", strings.Join(v.Chain, " -> "))
+	}
+
+	for _, c := range v.Checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAILED: " + c.Reason
+		}
+
+		fmt.Fprintf(&sb, "%-24s%s\n", checkLabels[c.Name]+":", status)
+	}
+
+	result := "valid"
+	if !v.Valid {
+		result = "invalid"
+	}
+
+	fmt.Fprintf(&sb, "%-24s%s\n", "Result:", result)
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
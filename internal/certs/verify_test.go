@@ -0,0 +1,106 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	rootTmpl, err := NewTemplate(pkix.Name{CommonName: "Test Root CA"}, now.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	rootTmpl.IsCA = true
+	rootTmpl.KeyUsage = x509.KeyUsageCertSign
+
+	root := createCertificate(t, rootTmpl, rootTmpl, rootKey, rootKey)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	leafTmpl, err := NewTemplate(pkix.Name{CommonName: "example.com"}, now.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("NewTemplate returned an error when one wasn't expected: %+v", err)
+	}
+
+	leafTmpl.DNSNames = []string{"example.com"}
+	leafTmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	leaf := createCertificate(t, leafTmpl, root, leafKey, rootKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	t.Run("Valid", func(t *testing.T) {
+		v := Verify(leaf, VerifyOptions{
+			Roots:        roots,
+			Hostname:     "example.com",
+			ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+
+		if !v.Valid || len(v.Checks) != 4 || len(v.Chain) != 2 {
+			t.Errorf("expected the certificate to be valid, got %+v", v)
+		}
+	})
+
+	t.Run("EveryFailure", func(t *testing.T) {
+		v := Verify(leaf, VerifyOptions{
+			Roots:        roots,
+			Time:         now.AddDate(0, 2, 0),
+			Hostname:     "wibble.com",
+			ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+
+		if v.Valid {
+			t.Fatalf("expected the certificate to be invalid")
+		}
+
+		// The chain is still built, even though the leaf has expired
+		want := map[string]bool{"chain": true, "validity": false, "hostname": false, "extKeyUsage": false}
+
+		for _, c := range v.Checks {
+			if c.OK != want[c.Name] {
+				t.Errorf("expected check %s to be %v, got %+v", c.Name, want[c.Name], c)
+			}
+		}
+	})
+
+	t.Run("UnknownAuthority", func(t *testing.T) {
+		v := Verify(leaf, VerifyOptions{Roots: x509.NewCertPool()})
+
+		if v.Valid || v.Checks[0].Name != "chain" || v.Checks[0].OK {
+			t.Errorf("expected the chain check to fail, got %+v", v)
+		}
+	})
+}
+
+func createCertificate(t *testing.T, tmpl, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate returned an error when one wasn't expected: %+v", err)
+	}
+
+	return c
+}
@@ -0,0 +1,165 @@
+// Package input implements reading lists of values, such as CIDRs, from
+// arguments, files and stdin, as shared by the cidr and ip commands.
+package input
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/simondrake/genc/internal/netaddr"
+)
+
+// Piped returns whether stdin is a pipe or file, rather than a terminal.
+func Piped() bool {
+	return !term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Open opens the file at path for reading, or stdin if path is "-".
+func Open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(path)
+}
+
+// Read returns the entries in args and the file at path, as List,
+// reading from stdin if there are no args or path and stdin is Piped.
+func Read(args []string, path string) ([]string, error) {
+	if path == "" && len(args) == 0 && Piped() {
+		path = "-"
+	}
+
+	return List(args, path)
+}
+
+// List returns the entries in args, followed by those in the file at
+// path, one per line. A path of "-" reads from stdin. Blank lines, and
+// comments starting with '#', are ignored.
+func List(args []string, path string) ([]string, error) {
+	list := append([]string(nil), args...)
+
+	if path == "" {
+		return list, nil
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	s := bufio.NewScanner(r)
+
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			list = append(list, line)
+		}
+	}
+
+	return list, s.Err()
+}
+
+// Prefixes parses the CIDRs in args, the JSON list in cidrs (as used by
+// cidr overlap), and the file at path, as read by Read.
+func Prefixes(args []string, cidrs, path string) ([]netip.Prefix, error) {
+	return prefixes(args, cidrs, path, netaddr.ParsePrefix)
+}
+
+// HostPrefixes is Prefixes, but allows CIDRs with host bits set, such as
+// 192.168.1.5/24, which are returned as written.
+func HostPrefixes(args []string, cidrs, path string) ([]netip.Prefix, error) {
+	return prefixes(args, cidrs, path, parseHostPrefix)
+}
+
+func prefixes(args []string, cidrs, path string, parse func(string) (netip.Prefix, error)) ([]netip.Prefix, error) {
+	if cidrs != "" {
+		args = append(append([]string(nil), args...), cidrs)
+	}
+
+	list, err := Read(args, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePrefixes(list, parse)
+}
+
+// PrefixList parses the CIDRs, or JSON lists of CIDRs, in values and the
+// file at path, as read by List. Unlike Prefixes, stdin is only read
+// if path is "-".
+func PrefixList(values []string, path string) ([]netip.Prefix, error) {
+	list, err := List(values, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePrefixes(list)
+}
+
+// HostPrefixList is PrefixList, but allows CIDRs with host bits set, which
+// are returned as written.
+func HostPrefixList(values []string, path string) ([]netip.Prefix, error) {
+	list, err := List(values, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseHostPrefixes(list)
+}
+
+// ParsePrefixes parses each of the CIDRs in list, or the JSON lists of CIDRs
+// in list, such as '["10.0.0.0/8", "192.168.0.0/16"]'.
+func ParsePrefixes(list []string) ([]netip.Prefix, error) {
+	return parsePrefixes(list, netaddr.ParsePrefix)
+}
+
+// ParseHostPrefixes is ParsePrefixes, but allows CIDRs with host bits set,
+// which are returned as written.
+func ParseHostPrefixes(list []string) ([]netip.Prefix, error) {
+	return parsePrefixes(list, parseHostPrefix)
+}
+
+func parseHostPrefix(s string) (netip.Prefix, error) {
+	return netip.ParsePrefix(strings.TrimSpace(s))
+}
+
+func parsePrefixes(list []string, parse func(string) (netip.Prefix, error)) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, c := range list {
+		if strings.HasPrefix(strings.TrimSpace(c), "[") {
+			var l []string
+
+			if err := json.Unmarshal([]byte(c), &l); err != nil {
+				return nil, fmt.Errorf("error parsing cidrs: %w", err)
+			}
+
+			ps, err := parsePrefixes(l, parse)
+			if err != nil {
+				return nil, err
+			}
+
+			prefixes = append(prefixes, ps...)
+
+			continue
+		}
+
+		p, err := parse(c)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CIDR '%s': %w", c, err)
+		}
+
+		prefixes = append(prefixes, p)
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,46 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadList(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "plan.txt")
+
+	if err := os.WriteFile(f, []byte("10.0.0.0/8 # corp\n\n  # reserved\n172.16.0.0/12\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile returned an error when one wasn't expected: %+v", err)
+	}
+
+	list, err := List([]string{"192.168.0.0/16"}, f)
+	if err != nil {
+		t.Fatalf("List returned an error when one wasn't expected: %+v", err)
+	}
+
+	if strings.Join(list, ",") != "192.168.0.0/16,10.0.0.0/8,172.16.0.0/12" {
+		t.Errorf("unexpected list %v", list)
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	list := []string{`["10.0.0.0/8", "192.168.1.5/24"]`, "2001:db8::1/32"}
+
+	if _, err := ParsePrefixes(list); err == nil {
+		t.Errorf("ParsePrefixes didn't return an error for CIDRs with host bits set")
+	}
+
+	prefixes, err := ParseHostPrefixes(list)
+	if err != nil {
+		t.Fatalf("ParseHostPrefixes returned an error when one wasn't expected: %+v", err)
+	}
+
+	if len(prefixes) != 3 || prefixes[1].String() != "192.168.1.5/24" || prefixes[2].String() != "2001:db8::1/32" {
+		t.Errorf("expected the CIDRs to be returned as written, got %v", prefixes)
+	}
+
+	if _, err := ParseHostPrefixes([]string{"192.168.1.5"}); err == nil {
+		t.Errorf("ParseHostPrefixes didn't return an error for an address without a prefix length")
+	}
+}
@@ -0,0 +1,61 @@
+package keys
+
+import "errors"
+
+// Algorithm implements a custom type to be used with Cobra.
+//
+// It ensures that the key algorithm is one of rsa, ecdsa or ed25519.
+type Algorithm string
+
+const (
+	RSA     Algorithm = "rsa"
+	ECDSA   Algorithm = "ecdsa"
+	Ed25519 Algorithm = "ed25519"
+)
+
+func (a *Algorithm) String() string {
+	return string(*a)
+}
+
+func (a *Algorithm) Set(v string) error {
+	switch Algorithm(v) {
+	case RSA, ECDSA, Ed25519:
+		*a = Algorithm(v)
+		return nil
+	default:
+		return errors.New(`must be one of rsa, ecdsa, or ed25519`)
+	}
+}
+
+func (a *Algorithm) Type() string {
+	return "[rsa,ecdsa,ed25519]"
+}
+
+// Format implements a custom type to be used with Cobra.
+//
+// It ensures that the private key format is one of pkcs1, pkcs8 or sec1.
+type Format string
+
+const (
+	PKCS1 Format = "pkcs1"
+	PKCS8 Format = "pkcs8"
+	SEC1  Format = "sec1"
+)
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(v string) error {
+	switch Format(v) {
+	case PKCS1, PKCS8, SEC1:
+		*f = Format(v)
+		return nil
+	default:
+		return errors.New(`must be one of pkcs1, pkcs8, or sec1`)
+	}
+}
+
+func (f *Format) Type() string {
+	return "[pkcs1,pkcs8,sec1]"
+}
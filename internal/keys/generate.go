@@ -0,0 +1,52 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+)
+
+// Generate creates a new private key. bits is only used for RSA keys and
+// curve for ECDSA keys, which can be one of P-224, P-256, P-384 or P-521.
+func Generate(algorithm Algorithm, bits int, curve string) (crypto.Signer, error) {
+	switch algorithm {
+	case RSA:
+		if bits < 2048 {
+			return nil, fmt.Errorf("rsa keys must be at least 2048 bits, got %d", bits)
+		}
+
+		return rsa.GenerateKey(rand.Reader, bits)
+	case ECDSA:
+		c, err := Curve(curve)
+		if err != nil {
+			return nil, err
+		}
+
+		return ecdsa.GenerateKey(c, rand.Reader)
+	case Ed25519:
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		return k, err
+	}
+
+	return nil, fmt.Errorf("unsupported key algorithm '%s'", algorithm)
+}
+
+// Curve returns the named elliptic curve.
+func Curve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-224":
+		return elliptic.P224(), nil
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported curve '%s', must be one of P-224, P-256, P-384, or P-521", name)
+}
@@ -0,0 +1,79 @@
+package keys
+
+import (
+	"crypto"
+	"encoding/pem"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		format    Format
+		pemType   string
+	}{
+		{name: "RSA PKCS1", algorithm: RSA, format: PKCS1, pemType: "RSA PRIVATE KEY"},
+		{name: "RSA PKCS8", algorithm: RSA, format: PKCS8, pemType: "PRIVATE KEY"},
+		{name: "ECDSA SEC1", algorithm: ECDSA, format: SEC1, pemType: "EC PRIVATE KEY"},
+		{name: "ECDSA PKCS8", algorithm: ECDSA, format: PKCS8, pemType: "PRIVATE KEY"},
+		{name: "Ed25519 PKCS8", algorithm: Ed25519, format: PKCS8, pemType: "PRIVATE KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Generate(tt.algorithm, 2048, "P-256")
+			if err != nil {
+				t.Fatalf("Generate returned an error when one wasn't expected: %+v", err)
+			}
+
+			b, err := MarshalPrivateKey(key, tt.format)
+			if err != nil {
+				t.Fatalf("MarshalPrivateKey returned an error when one wasn't expected: %+v", err)
+			}
+
+			if b.Type != tt.pemType {
+				t.Errorf("expected PEM type %q, got %q", tt.pemType, b.Type)
+			}
+
+			parsed, err := ParsePrivateKey(pem.EncodeToMemory(b), nil)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey returned an error when one wasn't expected: %+v", err)
+			}
+
+			pub, err := PublicKey(parsed)
+			if err != nil {
+				t.Fatalf("PublicKey returned an error when one wasn't expected: %+v", err)
+			}
+
+			if k, ok := pub.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(key.Public()) {
+				t.Errorf("parsed key does not match the generated key")
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := Generate(RSA, 1024, ""); err == nil {
+			t.Errorf("expected an error for a 1024 bit RSA key")
+		}
+
+		if _, err := Generate(ECDSA, 0, "P-123"); err == nil {
+			t.Errorf("expected an error for an unknown curve")
+		}
+
+		if _, err := MarshalPrivateKey(mustGenerate(t, Ed25519), SEC1); err == nil {
+			t.Errorf("expected an error marshalling an Ed25519 key as SEC1")
+		}
+	})
+}
+
+func mustGenerate(t *testing.T, algorithm Algorithm) crypto.Signer {
+	t.Helper()
+
+	key, err := Generate(algorithm, 2048, "P-256")
+	if err != nil {
+		t.Fatalf("Generate returned an error when one wasn't expected: %+v", err)
+	}
+
+	return key
+}
@@ -0,0 +1,251 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// jwk is a JSON Web Key (RFC 7517), for RSA, EC and OKP (Ed25519) keys.
+// Private key members are omitted for public keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+
+	// EC and OKP
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+
+	// The private exponent, or private key for EC and OKP
+	D string `json:"d,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// MarshalJWK encodes a private or public key as a JSON Web Key.
+func MarshalJWK(key interface{}) ([]byte, error) {
+	var k jwk
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if len(key.Primes) != 2 {
+			return nil, errors.New("multi-prime rsa keys are not supported")
+		}
+
+		key.Precompute()
+
+		k = rsaJWK(&key.PublicKey)
+		k.D = b64.EncodeToString(key.D.Bytes())
+		k.P = b64.EncodeToString(key.Primes[0].Bytes())
+		k.Q = b64.EncodeToString(key.Primes[1].Bytes())
+		k.DP = b64.EncodeToString(key.Precomputed.Dp.Bytes())
+		k.DQ = b64.EncodeToString(key.Precomputed.Dq.Bytes())
+		k.QI = b64.EncodeToString(key.Precomputed.Qinv.Bytes())
+	case *rsa.PublicKey:
+		k = rsaJWK(key)
+	case *ecdsa.PrivateKey:
+		var err error
+		if k, err = ecJWK(&key.PublicKey); err != nil {
+			return nil, err
+		}
+
+		k.D = b64.EncodeToString(key.D.FillBytes(make([]byte, curveSize(key.Curve))))
+	case *ecdsa.PublicKey:
+		var err error
+		if k, err = ecJWK(key); err != nil {
+			return nil, err
+		}
+	case ed25519.PrivateKey:
+		k = jwk{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(key.Public().(ed25519.PublicKey)), D: b64.EncodeToString(key.Seed())}
+	case ed25519.PublicKey:
+		k = jwk{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(key)}
+	default:
+		return nil, fmt.Errorf("unsupported key type '%T'", key)
+	}
+
+	return json.MarshalIndent(k, "", "  ")
+}
+
+func rsaJWK(pub *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		N:   b64.EncodeToString(pub.N.Bytes()),
+		E:   b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ecJWK(pub *ecdsa.PublicKey) (jwk, error) {
+	name := pub.Curve.Params().Name
+	if name == "P-224" {
+		return jwk{}, errors.New("the P-224 curve is not supported by JWK")
+	}
+
+	size := curveSize(pub.Curve)
+
+	return jwk{
+		Kty: "EC",
+		Crv: name,
+		X:   b64.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+		Y:   b64.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+	}, nil
+}
+
+func curveSize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
+
+// ParseJWK decodes a JSON Web Key, returning a private key if the private
+// members are present, and a public key otherwise.
+func ParseJWK(data []byte) (interface{}, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("error parsing jwk: %w", err)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		return parseRSAJWK(k)
+	case "EC":
+		return parseECJWK(k)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve '%s'", k.Crv)
+		}
+
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid jwk member 'x'")
+		}
+
+		if k.D == "" {
+			return ed25519.PublicKey(x), nil
+		}
+
+		d, err := b64.DecodeString(k.D)
+		if err != nil || len(d) != ed25519.SeedSize {
+			return nil, errors.New("invalid jwk member 'd'")
+		}
+
+		priv := ed25519.NewKeyFromSeed(d)
+		if !priv.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+			return nil, errors.New("jwk private key does not match the public key")
+		}
+
+		return priv, nil
+	}
+
+	return nil, fmt.Errorf("unsupported jwk key type '%s'", k.Kty)
+}
+
+func parseRSAJWK(k jwk) (interface{}, error) {
+	n, err := jwkInt(k.N, "n")
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := jwkInt(k.E, "e")
+	if err != nil {
+		return nil, err
+	}
+
+	pub := rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	if k.D == "" {
+		return &pub, nil
+	}
+
+	priv := &rsa.PrivateKey{PublicKey: pub}
+
+	if priv.D, err = jwkInt(k.D, "d"); err != nil {
+		return nil, err
+	}
+
+	p, err := jwkInt(k.P, "p")
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := jwkInt(k.Q, "q")
+	if err != nil {
+		return nil, err
+	}
+
+	priv.Primes = []*big.Int{p, q}
+
+	if err := priv.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rsa jwk: %w", err)
+	}
+
+	priv.Precompute()
+
+	return priv, nil
+}
+
+func parseECJWK(k jwk) (interface{}, error) {
+	c, err := Curve(k.Crv)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := jwkInt(k.X, "x")
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := jwkInt(k.Y, "y")
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.IsOnCurve(x, y) {
+		return nil, errors.New("invalid ec jwk: point is not on the curve")
+	}
+
+	pub := ecdsa.PublicKey{Curve: c, X: x, Y: y}
+
+	if k.D == "" {
+		return &pub, nil
+	}
+
+	d, err := jwkInt(k.D, "d")
+	if err != nil {
+		return nil, err
+	}
+
+	priv := &ecdsa.PrivateKey{PublicKey: pub, D: d}
+
+	// Check the private key matches the public key
+	if px, py := c.ScalarBaseMult(d.Bytes()); px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+		return nil, errors.New("jwk private key does not match the public key")
+	}
+
+	return priv, nil
+}
+
+func jwkInt(s, member string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("jwk member '%s' is missing", member)
+	}
+
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwk member '%s': %w", member, err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
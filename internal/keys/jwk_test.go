@@ -0,0 +1,76 @@
+package keys
+
+import (
+	"crypto"
+	"testing"
+)
+
+func TestJWK(t *testing.T) {
+	for _, algorithm := range []Algorithm{RSA, ECDSA, Ed25519} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			key := mustGenerate(t, algorithm)
+
+			b, err := MarshalJWK(key)
+			if err != nil {
+				t.Fatalf("MarshalJWK returned an error when one wasn't expected: %+v", err)
+			}
+
+			parsed, err := ParseJWK(b)
+			if err != nil {
+				t.Fatalf("ParseJWK returned an error when one wasn't expected: %+v", err)
+			}
+
+			if k, ok := parsed.(interface{ Equal(crypto.PrivateKey) bool }); !ok || !k.Equal(key) {
+				t.Errorf("parsed private key does not match the original key")
+			}
+
+			b, err = MarshalJWK(key.Public())
+			if err != nil {
+				t.Fatalf("MarshalJWK returned an error when one wasn't expected: %+v", err)
+			}
+
+			parsed, err = ParseJWK(b)
+			if err != nil {
+				t.Fatalf("ParseJWK returned an error when one wasn't expected: %+v", err)
+			}
+
+			if k, ok := parsed.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(key.Public()) {
+				t.Errorf("parsed public key does not match the original key")
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{
+			`{"kty":"oct","k":"c2VjcmV0"}`,
+			`{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}`,
+			`{"kty":"RSA","n":"AQ"}`,
+		} {
+			if _, err := ParseJWK([]byte(s)); err == nil {
+				t.Errorf("expected an error parsing %s", s)
+			}
+		}
+	})
+}
+
+func TestEncryptPKCS8(t *testing.T) {
+	key := mustGenerate(t, ECDSA)
+
+	der, err := EncryptPKCS8(key, []byte("genc"))
+	if err != nil {
+		t.Fatalf("EncryptPKCS8 returned an error when one wasn't expected: %+v", err)
+	}
+
+	parsed, err := ParsePrivateKey(der, func() ([]byte, error) { return []byte("genc"), nil })
+	if err != nil {
+		t.Fatalf("ParsePrivateKey returned an error when one wasn't expected: %+v", err)
+	}
+
+	if k, ok := parsed.(interface{ Equal(crypto.PrivateKey) bool }); !ok || !k.Equal(key) {
+		t.Errorf("decrypted key does not match the original key")
+	}
+
+	if _, err := ParsePrivateKey(der, func() ([]byte, error) { return []byte("wibble"), nil }); err == nil {
+		t.Errorf("expected an error decrypting with the wrong passphrase")
+	}
+}
@@ -0,0 +1,160 @@
+// Package keys loads private keys from the formats that genc commands accept.
+package keys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrPassphraseRequired is returned when a private key is encrypted, but no
+// passphrase was provided to decrypt it.
+var ErrPassphraseRequired = errors.New("private key is encrypted and a passphrase is required")
+
+// LoadPrivateKey reads the private key at path. See ParsePrivateKey for the
+// supported formats.
+func LoadPrivateKey(path string, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePrivateKey(b, passphrase)
+}
+
+// ParsePrivateKey parses a private key, which can be:
+//
+//   - PEM encoded PKCS#1 (RSA PRIVATE KEY), SEC1 (EC PRIVATE KEY) or PKCS#8
+//     (PRIVATE KEY), optionally encrypted with legacy Proc-Type headers
+//   - PEM encoded, encrypted PKCS#8 (ENCRYPTED PRIVATE KEY)
+//   - an OpenSSH private key, optionally encrypted
+//   - DER encoded PKCS#1, SEC1, PKCS#8 or encrypted PKCS#8
+//   - a PKCS#12 (.p12/.pfx) bundle
+//
+// The passphrase is only requested if the key is encrypted, and may be nil if
+// encrypted keys are not supported by the caller.
+func ParsePrivateKey(data []byte, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	if passphrase == nil {
+		passphrase = func() ([]byte, error) { return nil, ErrPassphraseRequired }
+	}
+
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		return parseDERPrivateKey(data, passphrase)
+	}
+
+	for rest := data; ; {
+		var b *pem.Block
+
+		b, rest = pem.Decode(rest)
+		if b == nil {
+			break
+		}
+
+		switch b.Type {
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY":
+			return parsePEMPrivateKey(b, passphrase)
+		case "ENCRYPTED PRIVATE KEY":
+			return parseEncryptedPKCS8(b.Bytes, passphrase)
+		case "OPENSSH PRIVATE KEY":
+			return parseOpenSSHPrivateKey(pem.EncodeToMemory(b), passphrase)
+		}
+	}
+
+	return nil, errors.New("no private key found in PEM data")
+}
+
+func parsePEMPrivateKey(b *pem.Block, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	der := b.Bytes
+
+	//nolint:staticcheck // Legacy PEM encryption is insecure, but still needs to be read
+	if x509.IsEncryptedPEMBlock(b) {
+		p, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+
+		//nolint:staticcheck // See above
+		der, err = x509.DecryptPEMBlock(b, p)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting private key: %w", err)
+		}
+	}
+
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	}
+
+	return x509.ParsePKCS8PrivateKey(der)
+}
+
+func parseOpenSSHPrivateKey(b []byte, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	k, err := ssh.ParseRawPrivateKey(b)
+
+	var pme *ssh.PassphraseMissingError
+	if errors.As(err, &pme) {
+		p, perr := passphrase()
+		if perr != nil {
+			return nil, perr
+		}
+
+		k, err = ssh.ParseRawPrivateKeyWithPassphrase(b, p)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing openssh private key: %w", err)
+	}
+
+	// ed25519 keys are returned as a pointer, unlike the x509 package
+	if ek, ok := k.(*ed25519.PrivateKey); ok {
+		return *ek, nil
+	}
+
+	return k, nil
+}
+
+func parseDERPrivateKey(der []byte, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	if k, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return k, nil
+	}
+
+	if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return k, nil
+	}
+
+	if k, err := x509.ParseECPrivateKey(der); err == nil {
+		return k, nil
+	}
+
+	if isEncryptedPKCS8(der) {
+		return parseEncryptedPKCS8(der, passphrase)
+	}
+
+	// DecodeChain is used, rather than Decode, as most bundles also hold CA
+	// certificates, which Decode rejects
+	k, _, _, err := pkcs12.DecodeChain(der, "")
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		p, perr := passphrase()
+		if perr != nil {
+			return nil, perr
+		}
+
+		k, _, _, err = pkcs12.DecodeChain(der, string(p))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key as PKCS#1, PKCS#8, SEC1 or PKCS#12: %w", err)
+	}
+
+	return k, nil
+}
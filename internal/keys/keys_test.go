@@ -0,0 +1,73 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPrivateKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		wantType interface{}
+	}{
+		{name: "PKCS8", file: "rsa.pem", wantType: &rsa.PrivateKey{}},
+		{name: "PKCS1", file: "rsa_pkcs1.pem", wantType: &rsa.PrivateKey{}},
+		{name: "PKCS8 DER", file: "rsa_pkcs8.der", wantType: &rsa.PrivateKey{}},
+		{name: "SEC1", file: "ec_sec1.pem", wantType: &ecdsa.PrivateKey{}},
+		{name: "Encrypted PKCS8 AES-256", file: "rsa_pkcs8_aes256.pem", wantType: &rsa.PrivateKey{}},
+		{name: "Encrypted PKCS8 DES-EDE3", file: "rsa_pkcs8_des3.pem", wantType: &rsa.PrivateKey{}},
+		{name: "Encrypted PKCS8 scrypt", file: "rsa_pkcs8_scrypt.pem", wantType: &rsa.PrivateKey{}},
+		{name: "Encrypted PKCS8 DER", file: "rsa_pkcs8_aes128.der", wantType: &rsa.PrivateKey{}},
+		{name: "Legacy Encrypted PEM", file: "rsa_legacy.pem", wantType: &rsa.PrivateKey{}},
+		{name: "OpenSSH", file: "ed25519_openssh.pem", wantType: ed25519.PrivateKey{}},
+		{name: "PKCS12", file: "rsa.p12", wantType: &rsa.PrivateKey{}},
+		{name: "PKCS12 With CA", file: "rsa_chain.p12", wantType: &rsa.PrivateKey{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := LoadPrivateKey(filepath.Join("testdata", tt.file), Passphrase(filepath.Join("testdata", "passphrase.txt")))
+			if err != nil {
+				t.Fatalf("LoadPrivateKey returned an error when one wasn't expected: %+v", err)
+			}
+
+			if reflect.TypeOf(k) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("LoadPrivateKey was expected to return '%T' but returned '%T'", tt.wantType, k)
+			}
+		})
+	}
+
+	t.Run("Incorrect Passphrase", func(t *testing.T) {
+		passphrase := func() ([]byte, error) { return []byte("wibble"), nil }
+
+		for _, f := range []string{"rsa_pkcs8_aes256.pem", "rsa_legacy.pem", "ed25519_openssh.pem", "rsa.p12", "rsa_chain.p12"} {
+			if _, err := LoadPrivateKey(filepath.Join("testdata", f), passphrase); err == nil {
+				t.Errorf("LoadPrivateKey was expected to return an error for '%s' but didn't", f)
+			}
+		}
+	})
+
+	t.Run("PKCS12 With CA Without Passphrase", func(t *testing.T) {
+		k, err := LoadPrivateKey(filepath.Join("testdata", "rsa_chain_nopass.p12"), nil)
+		if err != nil {
+			t.Fatalf("LoadPrivateKey returned an error when one wasn't expected: %+v", err)
+		}
+
+		if _, ok := k.(*rsa.PrivateKey); !ok {
+			t.Errorf("LoadPrivateKey was expected to return '*rsa.PrivateKey' but returned '%T'", k)
+		}
+	})
+
+	t.Run("No Passphrase", func(t *testing.T) {
+		_, err := LoadPrivateKey(filepath.Join("testdata", "rsa_pkcs8_aes256.pem"), nil)
+		if !errors.Is(err, ErrPassphraseRequired) {
+			t.Errorf("LoadPrivateKey was expected to return '%v' but returned '%v'", ErrPassphraseRequired, err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// MarshalPrivateKey encodes key in the given format. PKCS#1 is only
+// supported for RSA keys, and SEC1 for ECDSA keys.
+func MarshalPrivateKey(key crypto.PrivateKey, format Format) (*pem.Block, error) {
+	switch format {
+	case PKCS1:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("pkcs1 is only supported for rsa keys, got '%T'", key)
+		}
+
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case SEC1:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("sec1 is only supported for ecdsa keys, got '%T'", key)
+		}
+
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	case PKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported private key format '%s'", format)
+}
+
+// MarshalPublicKey encodes pub as a PKIX public key.
+func MarshalPublicKey(pub crypto.PublicKey) (*pem.Block, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{Type: "PUBLIC KEY", Bytes: der}, nil
+}
+
+// PublicKey returns the public half of key.
+func PublicKey(key crypto.PrivateKey) (crypto.PublicKey, error) {
+	s, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type '%T'", key)
+	}
+
+	return s.Public(), nil
+}
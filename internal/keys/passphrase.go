@@ -0,0 +1,82 @@
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// PassphraseFunc returns the passphrase used to decrypt a private key.
+type PassphraseFunc func() ([]byte, error)
+
+// Passphrase returns a PassphraseFunc that reads the passphrase from the file
+// at path, or prompts for it on the terminal if path is empty.
+//
+// The passphrase is only read once, no matter how many times the returned
+// function is called.
+func Passphrase(path string) PassphraseFunc {
+	return sync.OnceValues(func() ([]byte, error) {
+		if path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("error reading passphrase file: %w", err)
+			}
+
+			return bytes.TrimRight(b, "\r\n"), nil
+		}
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, errors.New("private key is encrypted, use --passphrase-file to provide the passphrase")
+		}
+
+		fmt.Fprint(os.Stderr, "Passphrase: ")
+		defer fmt.Fprintln(os.Stderr)
+
+		return term.ReadPassword(fd)
+	})
+}
+
+// NewPassphrase returns a PassphraseFunc that reads the passphrase used to
+// encrypt a private key from the file at path, or prompts for it on the
+// terminal, twice, if path is empty.
+func NewPassphrase(path string) PassphraseFunc {
+	return sync.OnceValues(func() ([]byte, error) {
+		if path != "" {
+			return Passphrase(path)()
+		}
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, errors.New("a passphrase is required to encrypt the private key, use a passphrase file to provide it")
+		}
+
+		fmt.Fprint(os.Stderr, "New passphrase: ")
+
+		p, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+
+		c, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(p, c) {
+			return nil, errors.New("passphrases do not match")
+		}
+
+		return p, nil
+	})
+}
@@ -0,0 +1,65 @@
+package keys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// LoadPublicKey reads the public key at path. See ParsePublicKey for the
+// supported formats.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePublicKey(b)
+}
+
+// ParsePublicKey parses a public key, which can be PEM or DER encoded PKIX
+// (PUBLIC KEY), PEM encoded PKCS#1 (RSA PUBLIC KEY), or in the OpenSSH
+// authorized_keys format.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("ssh-")) || bytes.HasPrefix(bytes.TrimSpace(data), []byte("ecdsa-")) {
+		k, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing openssh public key: %w", err)
+		}
+
+		ck, ok := k.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported openssh public key type '%s'", k.Type())
+		}
+
+		return ck.CryptoPublicKey(), nil
+	}
+
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		return x509.ParsePKIXPublicKey(data)
+	}
+
+	for rest := data; ; {
+		var b *pem.Block
+
+		b, rest = pem.Decode(rest)
+		if b == nil {
+			break
+		}
+
+		switch b.Type {
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(b.Bytes)
+		case "RSA PUBLIC KEY":
+			return x509.ParsePKCS1PublicKey(b.Bytes)
+		}
+	}
+
+	return nil, errors.New("no public key found in PEM data")
+}
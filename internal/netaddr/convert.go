@@ -0,0 +1,157 @@
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// PTR returns the in-addr.arpa or ip6.arpa name used to look up the PTR
+// record for a.
+func PTR(a netip.Addr) string {
+	a = a.WithZone("")
+
+	suffix := "in-addr.arpa."
+	if a.Is6() {
+		suffix = "ip6.arpa."
+	}
+
+	return strings.Join(reverseLabels(a), ".") + "." + suffix
+}
+
+// ParsePTR parses s as the in-addr.arpa or ip6.arpa name of an address.
+func ParsePTR(s string) (netip.Addr, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(labels) != 4 {
+			return netip.Addr{}, fmt.Errorf("'%s' must have 4 octets", s)
+		}
+
+		for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+			labels[i], labels[j] = labels[j], labels[i]
+		}
+
+		return netip.ParseAddr(strings.Join(labels, "."))
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(labels) != 32 {
+			return netip.Addr{}, fmt.Errorf("'%s' must have 32 nibbles", s)
+		}
+
+		var sb strings.Builder
+
+		for i := len(labels) - 1; i >= 0; i-- {
+			if len(labels[i]) != 1 {
+				return netip.Addr{}, fmt.Errorf("'%s' isn't a nibble", labels[i])
+			}
+
+			sb.WriteString(labels[i])
+
+			if i%4 == 0 && i > 0 {
+				sb.WriteByte(':')
+			}
+		}
+
+		return netip.ParseAddr(sb.String())
+	default:
+		return netip.Addr{}, errors.New("must end with in-addr.arpa or ip6.arpa")
+	}
+}
+
+// Parse parses s as an address in any of the forms written by the ip
+// commands: text, such as 192.0.2.1 or 2001:db8::1, a decimal integer, hex
+// with a 0x prefix, binary with a 0b prefix or as written by Binary, or a PTR
+// name.
+//
+// Numbers are IPv4 addresses if they fit and is6 isn't set.
+func Parse(s string, is6 bool) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+
+	if a, err := netip.ParseAddr(s); err == nil {
+		return a, nil
+	}
+
+	if strings.HasSuffix(strings.TrimSuffix(strings.ToLower(s), "."), ".arpa") {
+		return ParsePTR(s)
+	}
+
+	var (
+		digits string
+		base   int
+	)
+
+	switch {
+	case strings.HasPrefix(strings.ToLower(s), "0x"):
+		// Hex and binary wider than an IPv4 address, such as those written
+		// by Hex, are IPv6 addresses even if the value would fit
+		digits, base = s[2:], 16
+		is6 = is6 || len(digits) > 8
+	case strings.HasPrefix(strings.ToLower(s), "0b"):
+		digits, base = s[2:], 2
+		is6 = is6 || len(digits) > 32
+	case isGroupedBinary(s):
+		digits, base = strings.NewReplacer(".", "", ":", "").Replace(s), 2
+		// The width of grouped binary gives the family
+		is6 = len(digits) == 128
+	default:
+		digits, base = s, 10
+	}
+
+	i, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("unable to parse '%s' as an IP address, integer, hex, binary or PTR name", s)
+	}
+
+	if !is6 && i.BitLen() > 32 {
+		is6 = true
+	}
+
+	return FromInt(i, is6)
+}
+
+// isGroupedBinary returns whether s is an address in binary, as written by
+// Binary.
+func isGroupedBinary(s string) bool {
+	sep, groups, width := ".", 4, 8
+	if strings.Contains(s, ":") {
+		sep, groups, width = ":", 8, 16
+	}
+
+	parts := strings.Split(s, sep)
+	if len(parts) != groups {
+		return false
+	}
+
+	for _, p := range parts {
+		if len(p) != width || strings.Trim(p, "01") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Add returns the address n addresses after a, or before if n is negative.
+func Add(a netip.Addr, n *big.Int) (netip.Addr, error) {
+	b, err := FromInt(new(big.Int).Add(ToInt(a), n), a.Is6())
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("the result is outside the %s address space", Family(a))
+	}
+
+	return b, nil
+}
+
+// Diff returns the number of addresses from a to b, which is negative if b is
+// before a.
+func Diff(a, b netip.Addr) (*big.Int, error) {
+	if a.BitLen() != b.BitLen() {
+		return nil, errors.New("the addresses must be the same address family")
+	}
+
+	return new(big.Int).Sub(ToInt(b), ToInt(a)), nil
+}
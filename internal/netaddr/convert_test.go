@@ -0,0 +1,79 @@
+package netaddr
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ipv6  bool
+		ip    string
+	}{
+		{"Text", "192.0.2.1", false, "192.0.2.1"},
+		{"Integer", "3221225985", false, "192.0.2.1"},
+		{"IntegerIPv6", "1", true, "::1"},
+		{"LargeInteger", "42540766411282592856903984951653826561", false, "2001:db8::1"},
+		{"Hex", "0xc0000201", false, "192.0.2.1"},
+		{"WideHex", "0x00000000000000000000000000000001", false, "::1"},
+		{"Binary", "0b11000000000000000000001000000001", false, "192.0.2.1"},
+		{"GroupedBinary", "11000000.00000000.00000010.00000001", false, "192.0.2.1"},
+		{"PTR", "1.2.0.192.in-addr.arpa.", false, "192.0.2.1"},
+		{"PTRIPv6", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", false, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse(tt.input, tt.ipv6)
+			if err != nil {
+				t.Fatalf("Parse returned an error when one wasn't expected: %+v", err)
+			}
+
+			if a.String() != tt.ip {
+				t.Errorf("expected %s but got %s", tt.ip, a)
+			}
+		})
+	}
+
+	for _, s := range []string{"wibble", "-1", "0x1000000000000000000000000000000000", "1.2.3.in-addr.arpa"} {
+		if _, err := Parse(s, false); err == nil {
+			t.Errorf("expected an error parsing '%s'", s)
+		}
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, s := range []string{"0.0.0.0", "10.1.2.3", "255.255.255.255", "::", "2001:db8::1", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"} {
+			a := netip.MustParseAddr(s)
+
+			for _, f := range []string{ToInt(a).String(), Hex(a), Binary(a), PTR(a)} {
+				got, err := Parse(f, a.Is6())
+				if err != nil || got != a {
+					t.Errorf("expected %s to parse as %s but got %s (%v)", f, a, got, err)
+				}
+			}
+		}
+	})
+}
+
+func TestArithmetic(t *testing.T) {
+	a := netip.MustParseAddr("10.0.0.255")
+
+	if b, err := Add(a, big.NewInt(1)); err != nil || b.String() != "10.0.1.0" {
+		t.Errorf("expected 10.0.1.0 but got %s (%v)", b, err)
+	}
+
+	if _, err := Add(a, big.NewInt(-167772416)); err == nil {
+		t.Errorf("Add was expected to return an error but didn't")
+	}
+
+	if d, err := Diff(a, netip.MustParseAddr("10.0.0.0")); err != nil || d.Int64() != -255 {
+		t.Errorf("expected -255 but got %s (%v)", d, err)
+	}
+
+	if _, err := Diff(a, netip.MustParseAddr("::1")); err == nil {
+		t.Errorf("Diff was expected to return an error but didn't")
+	}
+}
@@ -0,0 +1,142 @@
+// Package netaddr implements the address arithmetic shared by the cidr and ip
+// commands. Addresses are converted to big integers so that IPv6 prefixes,
+// which can hold up to 2^128 addresses, are handled exactly.
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefix parses s as a CIDR block, returning an error if it has host
+// bits set.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	if p != p.Masked() {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR block - did you mean '%s'?", p.Masked())
+	}
+
+	return p, nil
+}
+
+// ToInt returns a as an unsigned integer.
+func ToInt(a netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(a.AsSlice())
+}
+
+// FromInt returns the IPv4, or IPv6 if is6 is set, address with the value i.
+func FromInt(i *big.Int, is6 bool) (netip.Addr, error) {
+	size := 4
+	if is6 {
+		size = 16
+	}
+
+	if i.Sign() < 0 || i.BitLen() > size*8 {
+		return netip.Addr{}, errors.New("address is out of range")
+	}
+
+	a, _ := netip.AddrFromSlice(i.FillBytes(make([]byte, size)))
+
+	return a, nil
+}
+
+// Family returns IPv4 or IPv6.
+func Family(a netip.Addr) string {
+	if a.Is4() {
+		return "IPv4"
+	}
+
+	return "IPv6"
+}
+
+// Size returns the number of addresses in p.
+func Size(p netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+}
+
+// Last returns the highest address in p.
+func Last(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	w := Wildcard(p).AsSlice()
+
+	for i := range b {
+		b[i] |= w[i]
+	}
+
+	a, _ := netip.AddrFromSlice(b)
+
+	return a
+}
+
+// Mask returns the network mask of p, as an address.
+func Mask(p netip.Prefix) netip.Addr {
+	b := make([]byte, p.Addr().BitLen()/8)
+
+	for i := 0; i < p.Bits(); i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+
+	a, _ := netip.AddrFromSlice(b)
+
+	return a
+}
+
+// Wildcard returns the inverse of the network mask of p, as an address.
+func Wildcard(p netip.Prefix) netip.Addr {
+	b := Mask(p).AsSlice()
+
+	for i := range b {
+		b[i] = ^b[i]
+	}
+
+	a, _ := netip.AddrFromSlice(b)
+
+	return a
+}
+
+// ReverseZone returns the in-addr.arpa or ip6.arpa zone that contains p.
+//
+// Reverse zones are delegated on octet (IPv4) or nibble (IPv6) boundaries,
+// so if p doesn't fall on one, the zone of the enclosing boundary is returned,
+// and exact is false.
+func ReverseZone(p netip.Prefix) (zone string, exact bool) {
+	step, suffix := 8, "in-addr.arpa."
+	if p.Addr().Is6() {
+		step, suffix = 4, "ip6.arpa."
+	}
+
+	bits := p.Bits() - p.Bits()%step
+	labels := reverseLabels(p.Addr())
+
+	zone = strings.Join(labels[len(labels)-bits/step:], ".")
+	if zone != "" {
+		zone += "."
+	}
+
+	return zone + suffix, bits == p.Bits()
+}
+
+// reverseLabels returns the octets of an IPv4 address, or the nibbles of an
+// IPv6 address, in reverse order.
+func reverseLabels(a netip.Addr) []string {
+	var labels []string
+
+	b := a.AsSlice()
+
+	for i := len(b) - 1; i >= 0; i-- {
+		if a.Is4() {
+			labels = append(labels, fmt.Sprint(b[i]))
+		} else {
+			labels = append(labels, fmt.Sprintf("%x", b[i]&0x0f), fmt.Sprintf("%x", b[i]>>4))
+		}
+	}
+
+	return labels
+}
@@ -0,0 +1,83 @@
+package netaddr
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		size     string
+		last     string
+		mask     string
+		wildcard string
+		zone     string
+		exact    bool
+	}{
+		{"192.168.1.0/24", "256", "192.168.1.255", "255.255.255.0", "0.0.0.255", "1.168.192.in-addr.arpa.", true},
+		{"10.16.0.0/12", "1048576", "10.31.255.255", "255.240.0.0", "0.15.255.255", "10.in-addr.arpa.", false},
+		{"2001:db8::/32", "79228162514264337593543950336", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", "ffff:ffff::", "::ffff:ffff:ffff:ffff:ffff:ffff", "8.b.d.0.1.0.0.2.ip6.arpa.", true},
+		{"2001:db8::/64", "18446744073709551616", "2001:db8::ffff:ffff:ffff:ffff", "ffff:ffff:ffff:ffff::", "::ffff:ffff:ffff:ffff", "0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", true},
+		{"::/0", "340282366920938463463374607431768211456", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "ip6.arpa.", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			p, err := ParsePrefix(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParsePrefix returned an error when one wasn't expected: %+v", err)
+			}
+
+			if s := Size(p).String(); s != tt.size {
+				t.Errorf("expected size to be %s but was %s", tt.size, s)
+			}
+
+			if l := Last(p).String(); l != tt.last {
+				t.Errorf("expected last address to be %s but was %s", tt.last, l)
+			}
+
+			if m := Mask(p).String(); m != tt.mask {
+				t.Errorf("expected mask to be %s but was %s", tt.mask, m)
+			}
+
+			if w := Wildcard(p).String(); w != tt.wildcard {
+				t.Errorf("expected wildcard to be %s but was %s", tt.wildcard, w)
+			}
+
+			if zone, exact := ReverseZone(p); zone != tt.zone || exact != tt.exact {
+				t.Errorf("expected reverse zone to be %s (%v) but was %s (%v)", tt.zone, tt.exact, zone, exact)
+			}
+		})
+	}
+
+	t.Run("HostBits", func(t *testing.T) {
+		if _, err := ParsePrefix("192.168.1.5/24"); err == nil {
+			t.Errorf("expected an error parsing a prefix with host bits set")
+		}
+	})
+}
+
+func TestInt(t *testing.T) {
+	for _, s := range []string{"0.0.0.0", "10.1.2.3", "255.255.255.255", "::", "2001:db8::1", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"} {
+		a := netip.MustParseAddr(s)
+
+		b, err := FromInt(ToInt(a), a.Is6())
+		if err != nil {
+			t.Fatalf("FromInt returned an error when one wasn't expected: %+v", err)
+		}
+
+		if a != b {
+			t.Errorf("expected %s to round trip, got %s", a, b)
+		}
+	}
+
+	if _, err := FromInt(new(big.Int).Lsh(big.NewInt(1), 32), false); err == nil {
+		t.Errorf("expected an error converting 2^32 to an IPv4 address")
+	}
+
+	if _, err := FromInt(big.NewInt(-1), true); err == nil {
+		t.Errorf("expected an error converting a negative number to an address")
+	}
+}
@@ -0,0 +1,237 @@
+package netaddr
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Relationship describes how one prefix, or set of prefixes, relates to
+// another.
+type Relationship string
+
+const (
+	Equal       Relationship = "equal"
+	Contains    Relationship = "contains"
+	ContainedBy Relationship = "contained-by"
+	// Partial is only possible between sets of prefixes, as two prefixes
+	// that overlap are either equal or one contains the other
+	Partial  Relationship = "partial"
+	Disjoint Relationship = "disjoint"
+)
+
+// Overlap is a pair of overlapping prefixes, identified by their index in the
+// lists they were found in.
+type Overlap struct {
+	A            int
+	B            int
+	Relationship Relationship
+	// Intersection is the addresses the prefixes have in common, which is
+	// always the smaller of the two
+	Intersection netip.Prefix
+}
+
+// Overlaps returns every pair of prefixes, one from a and one from b, that
+// overlap, sorted by their index in a and then b.
+func Overlaps(a, b []netip.Prefix) []Overlap {
+	return overlaps(a, b, true)
+}
+
+// SelfOverlaps returns every pair of prefixes in prefixes that overlap, with
+// A less than B, sorted by A and then B.
+func SelfOverlaps(prefixes []netip.Prefix) []Overlap {
+	return overlaps(prefixes, nil, false)
+}
+
+type overlapEntry struct {
+	prefix netip.Prefix
+	set    int
+	index  int
+}
+
+// overlaps finds overlapping pairs by sorting the prefixes by address, with
+// larger blocks first, and sweeping through them. As prefixes only overlap if
+// one contains the other, the prefixes that contain the current prefix form a
+// chain of nested blocks that is kept on a stack, so each pair is found
+// without comparing every prefix to every other.
+func overlaps(a, b []netip.Prefix, cross bool) []Overlap {
+	entries := make([]overlapEntry, 0, len(a)+len(b))
+
+	for i, p := range a {
+		entries = append(entries, overlapEntry{prefix: p.Masked(), set: 0, index: i})
+	}
+
+	for i, p := range b {
+		entries = append(entries, overlapEntry{prefix: p.Masked(), set: 1, index: i})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		ei, ej := entries[i], entries[j]
+
+		if c := ei.prefix.Addr().Compare(ej.prefix.Addr()); c != 0 {
+			return c < 0
+		}
+
+		if ei.prefix.Bits() != ej.prefix.Bits() {
+			return ei.prefix.Bits() < ej.prefix.Bits()
+		}
+
+		if ei.set != ej.set {
+			return ei.set < ej.set
+		}
+
+		return ei.index < ej.index
+	})
+
+	var (
+		found []Overlap
+		stack []overlapEntry
+	)
+
+	for _, e := range entries {
+		// Drop the blocks that end before this one starts, or are a
+		// different address family
+		for len(stack) > 0 {
+			top := stack[len(stack)-1].prefix
+			if top.Addr().BitLen() == e.prefix.Addr().BitLen() && !Last(top).Less(e.prefix.Addr()) {
+				break
+			}
+
+			stack = stack[:len(stack)-1]
+		}
+
+		for _, s := range stack {
+			if cross && s.set == e.set {
+				continue
+			}
+
+			first, second := s, e
+			if first.set > second.set || (first.set == second.set && first.index > second.index) {
+				first, second = second, first
+			}
+
+			found = append(found, Overlap{
+				A:            first.index,
+				B:            second.index,
+				Relationship: relationship(first.prefix, second.prefix),
+				Intersection: e.prefix,
+			})
+		}
+
+		stack = append(stack, e)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].A != found[j].A {
+			return found[i].A < found[j].A
+		}
+
+		return found[i].B < found[j].B
+	})
+
+	return found
+}
+
+// relationship returns how a relates to b, which must overlap.
+func relationship(a, b netip.Prefix) Relationship {
+	switch {
+	case a.Bits() == b.Bits():
+		return Equal
+	case a.Bits() < b.Bits():
+		return Contains
+	default:
+		return ContainedBy
+	}
+}
+
+// CompareSets returns how the addresses covered by a relate to those covered
+// by b, and the minimal list of prefixes that cover the addresses in both.
+func CompareSets(a, b []netip.Prefix) (Relationship, []netip.Prefix) {
+	both, onlyA, onlyB := compareRanges(PrefixRanges(a), PrefixRanges(b))
+
+	var common []netip.Prefix
+
+	for _, r := range both {
+		common = append(common, r.Prefixes()...)
+	}
+
+	switch {
+	case len(both) == 0:
+		return Disjoint, common
+	case len(onlyA) == 0 && len(onlyB) == 0:
+		return Equal, common
+	case len(onlyB) == 0:
+		return Contains, common
+	case len(onlyA) == 0:
+		return ContainedBy, common
+	default:
+		return Partial, common
+	}
+}
+
+// compareRanges splits the addresses in a and b into those in both, those
+// only in a, and those only in b.
+//
+// The ranges are merged, which sorts them IPv4 before IPv6, and swept once,
+// splitting the current range from each list wherever it starts or ends
+// within the other.
+func compareRanges(a, b []Range) (both, onlyA, onlyB []Range) {
+	a, b = MergeRanges(a), MergeRanges(b)
+
+	var (
+		i, j         int
+		ra, rb       Range
+		haveA, haveB bool
+	)
+
+	for {
+		if !haveA && i < len(a) {
+			ra, haveA = a[i], true
+			i++
+		}
+
+		if !haveB && j < len(b) {
+			rb, haveB = b[j], true
+			j++
+		}
+
+		switch {
+		case !haveA && !haveB:
+			return both, onlyA, onlyB
+		case !haveB || (haveA && ra.To.Less(rb.From)):
+			onlyA, haveA = append(onlyA, ra), false
+		case !haveA || rb.To.Less(ra.From):
+			onlyB, haveB = append(onlyB, rb), false
+		default:
+			// The ranges overlap, so the part before the later of the two
+			// starts is only in one of them
+			if ra.From.Less(rb.From) {
+				onlyA = append(onlyA, Range{From: ra.From, To: rb.From.Prev()})
+				ra.From = rb.From
+			} else if rb.From.Less(ra.From) {
+				onlyB = append(onlyB, Range{From: rb.From, To: ra.From.Prev()})
+				rb.From = ra.From
+			}
+
+			end := ra.To
+			if rb.To.Less(end) {
+				end = rb.To
+			}
+
+			both = append(both, Range{From: ra.From, To: end})
+
+			// Whatever is left of the longer range is compared with the next
+			// range in the other list
+			if ra.To == end {
+				haveA = false
+			} else {
+				ra.From = end.Next()
+			}
+
+			if rb.To == end {
+				haveB = false
+			} else {
+				rb.From = end.Next()
+			}
+		}
+	}
+}
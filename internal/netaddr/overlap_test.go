@@ -0,0 +1,165 @@
+package netaddr
+
+import (
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestOverlaps(t *testing.T) {
+	a := mustParsePrefixes(t, []string{"10.0.0.0/16", "192.168.0.0/24", "2001:db8::/32"})
+	b := mustParsePrefixes(t, []string{"10.0.1.0/24", "10.0.0.0/8", "2001:db8::/32", "172.16.0.0/12"})
+
+	want := []Overlap{
+		{A: 0, B: 0, Relationship: Contains, Intersection: b[0]},
+		{A: 0, B: 1, Relationship: ContainedBy, Intersection: a[0]},
+		{A: 2, B: 2, Relationship: Equal, Intersection: b[2]},
+	}
+
+	if got := Overlaps(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 100; i++ {
+			prefixes := make([]netip.Prefix, 20)
+
+			for j := range prefixes {
+				a := netip.AddrFrom4([4]byte{10, 0, byte(r.Intn(4)), byte(r.Intn(256))})
+				prefixes[j] = netip.PrefixFrom(a, 20+r.Intn(13)).Masked()
+			}
+
+			var want []Overlap
+
+			for j := range prefixes {
+				for k := j + 1; k < len(prefixes); k++ {
+					if prefixes[j].Overlaps(prefixes[k]) {
+						want = append(want, Overlap{A: j, B: k})
+					}
+				}
+			}
+
+			got := SelfOverlaps(prefixes)
+			if len(got) != len(want) {
+				t.Fatalf("expected %d overlaps in %v but got %d", len(want), prefixes, len(got))
+			}
+
+			for j := range got {
+				if got[j].A != want[j].A || got[j].B != want[j].B {
+					t.Fatalf("expected %+v but got %+v", want[j], got[j])
+				}
+			}
+		}
+	})
+}
+
+func TestCompareSets(t *testing.T) {
+	tests := []struct {
+		name         string
+		a            []string
+		b            []string
+		relationship Relationship
+		intersection []string
+	}{
+		{"Equal", []string{"10.0.0.0/24", "10.0.1.0/24"}, []string{"10.0.0.0/23"}, Equal, []string{"10.0.0.0/23"}},
+		{"Contains", []string{"10.0.0.0/8"}, []string{"10.1.0.0/16", "10.2.0.0/16"}, Contains, []string{"10.1.0.0/16", "10.2.0.0/16"}},
+		{"ContainedBy", []string{"10.1.0.0/16"}, []string{"10.0.0.0/8", "2001:db8::/32"}, ContainedBy, []string{"10.1.0.0/16"}},
+		{"Partial", []string{"10.0.0.0/16", "10.1.0.0/16"}, []string{"10.1.0.0/16", "10.2.0.0/16"}, Partial, []string{"10.1.0.0/16"}},
+		{"Spanning", []string{"10.0.0.0/25", "10.0.0.128/26"}, []string{"10.0.0.64/26", "10.0.0.160/27", "10.0.0.192/26"}, Partial, []string{"10.0.0.64/26", "10.0.0.160/27"}},
+		{"Families", []string{"10.0.0.0/8", "2001:db8::/32"}, []string{"2001:db8::/48"}, Contains, []string{"2001:db8::/48"}},
+		{"Disjoint", []string{"10.0.0.0/8"}, []string{"::/0"}, Disjoint, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relationship, intersection := CompareSets(mustParsePrefixes(t, tt.a), mustParsePrefixes(t, tt.b))
+			if relationship != tt.relationship {
+				t.Errorf("expected %s but got %s", tt.relationship, relationship)
+			}
+
+			if want := mustParsePrefixes(t, tt.intersection); !reflect.DeepEqual(intersection, want) && len(want)+len(intersection) > 0 {
+				t.Errorf("expected the intersection %v but got %v", want, intersection)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		random := func() []netip.Prefix {
+			var prefixes []netip.Prefix
+
+			for j := 0; j < 5; j++ {
+				a := netip.AddrFrom4([4]byte{10, 0, 0, byte(r.Intn(256))})
+				prefixes = append(prefixes, netip.PrefixFrom(a, 24+r.Intn(9)).Masked())
+			}
+
+			return prefixes
+		}
+
+		for i := 0; i < 100; i++ {
+			a, b := random(), random()
+
+			ca, cb := coverage(a), coverage(b)
+
+			var (
+				want         [256]bool
+				onlyA, onlyB bool
+			)
+
+			for j := range want {
+				want[j] = ca[j] && cb[j]
+				onlyA = onlyA || (ca[j] && !cb[j])
+				onlyB = onlyB || (cb[j] && !ca[j])
+			}
+
+			relationship, intersection := CompareSets(a, b)
+			if coverage(intersection) != want {
+				t.Fatalf("the intersection of %v and %v covered the wrong addresses", a, b)
+			}
+
+			wantRelationship := Partial
+
+			switch {
+			case want == [256]bool{}:
+				wantRelationship = Disjoint
+			case !onlyA && !onlyB:
+				wantRelationship = Equal
+			case !onlyB:
+				wantRelationship = Contains
+			case !onlyA:
+				wantRelationship = ContainedBy
+			}
+
+			if relationship != wantRelationship {
+				t.Fatalf("expected %v and %v to be %s but got %s", a, b, wantRelationship, relationship)
+			}
+		}
+	})
+
+	t.Run("Large", func(t *testing.T) {
+		// 50,000 /30s against the upper half of each, which would be slow if
+		// every prefix were compared with every other
+		var a, b []netip.Prefix
+
+		for i := 0; i < 50000; i++ {
+			addr := netip.AddrFrom4([4]byte{10, byte(i >> 14), byte(i >> 6), byte(i << 2)})
+
+			a = append(a, netip.PrefixFrom(addr, 30))
+			b = append(b, netip.PrefixFrom(addr.Next().Next(), 31))
+		}
+
+		relationship, intersection := CompareSets(a, b)
+		if relationship != Contains {
+			t.Errorf("expected %s but got %s", Contains, relationship)
+		}
+
+		// The /30s are adjacent, so the intersection is every other /31
+		if len(intersection) != len(b) || intersection[0] != b[0] || intersection[len(b)-1] != b[len(b)-1] {
+			t.Errorf("expected the intersection to be the %d prefixes in b, got %d", len(b), len(intersection))
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Range is an inclusive range of addresses of the same family.
+type Range struct {
+	From netip.Addr `json:"from"`
+	To   netip.Addr `json:"to"`
+}
+
+// ParseRange parses s as a range of addresses, such as 10.0.0.5-10.0.1.200,
+// or a single address.
+func ParseRange(s string) (Range, error) {
+	from, to, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		to = from
+	}
+
+	f, err := netip.ParseAddr(strings.TrimSpace(from))
+	if err != nil {
+		return Range{}, err
+	}
+
+	t, err := netip.ParseAddr(strings.TrimSpace(to))
+	if err != nil {
+		return Range{}, err
+	}
+
+	return NewRange(f, t)
+}
+
+// NewRange returns the range between from and to, which must be addresses of
+// the same family, with from not after to.
+func NewRange(from, to netip.Addr) (Range, error) {
+	from, to = from.WithZone(""), to.WithZone("")
+
+	if from.BitLen() != to.BitLen() {
+		return Range{}, errors.New("the start and end of the range must be the same address family")
+	}
+
+	if to.Less(from) {
+		return Range{}, fmt.Errorf("the start of the range (%s) is after the end (%s)", from, to)
+	}
+
+	return Range{From: from, To: to}, nil
+}
+
+func (r Range) String() string {
+	return r.From.String() + "-" + r.To.String()
+}
+
+// Size returns the number of addresses in r.
+func (r Range) Size() *big.Int {
+	n := new(big.Int).Sub(ToInt(r.To), ToInt(r.From))
+
+	return n.Add(n, big.NewInt(1))
+}
+
+// PrefixRange returns the range of addresses in p.
+func PrefixRange(p netip.Prefix) Range {
+	return Range{From: p.Masked().Addr(), To: Last(p)}
+}
+
+// Prefixes returns the minimal list of prefixes that exactly cover r.
+func (r Range) Prefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for from := r.From; from.IsValid() && from.Compare(r.To) <= 0; {
+		// Find the largest block that starts at from and ends within r
+		p := netip.PrefixFrom(from, from.BitLen())
+
+		for bits := 0; bits < from.BitLen(); bits++ {
+			candidate := netip.PrefixFrom(from, bits)
+			if candidate.Masked().Addr() == from && Last(candidate).Compare(r.To) <= 0 {
+				p = candidate
+				break
+			}
+		}
+
+		prefixes = append(prefixes, p)
+
+		// Next returns an invalid address once the end of the address space
+		// is reached, which ends the loop
+		from = Last(p).Next()
+	}
+
+	return prefixes
+}
+
+// MergeRanges sorts ranges, IPv4 before IPv6, and combines those that
+// overlap or are adjacent.
+func MergeRanges(ranges []Range) []Range {
+	sorted := append([]Range(nil), ranges...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].From.Less(sorted[j].From)
+	})
+
+	var merged []Range
+
+	for _, r := range sorted {
+		if n := len(merged); n > 0 {
+			prev := &merged[n-1]
+			next := prev.To.Next()
+
+			if prev.From.BitLen() == r.From.BitLen() && (!next.IsValid() || r.From.Compare(next) <= 0) {
+				if r.To.Compare(prev.To) > 0 {
+					prev.To = r.To
+				}
+
+				continue
+			}
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// Merge returns the minimal list of prefixes that cover the same addresses as
+// prefixes, sorted with IPv4 before IPv6.
+func Merge(prefixes []netip.Prefix) []netip.Prefix {
+	var merged []netip.Prefix
+
+	for _, r := range MergeRanges(PrefixRanges(prefixes)) {
+		merged = append(merged, r.Prefixes()...)
+	}
+
+	return merged
+}
+
+// SubtractRanges returns the parts of ranges that aren't in exclude, merged
+// and sorted as MergeRanges.
+func SubtractRanges(ranges, exclude []Range) []Range {
+	var remaining []Range
+
+	exclude = MergeRanges(exclude)
+
+	// Both lists are sorted, IPv4 before IPv6, so the exclude ranges are
+	// swept once, with j at the first that could overlap the current range
+	j := 0
+
+	for _, r := range MergeRanges(ranges) {
+		cur, done := r, false
+
+		for j < len(exclude) && exclude[j].To.Less(cur.From) {
+			j++
+		}
+
+		// The last exclude range can extend into the next range, so j is
+		// only moved past ranges that end before it
+		for _, e := range exclude[j:] {
+			if cur.To.Less(e.From) {
+				break
+			}
+
+			if cur.From.Less(e.From) {
+				remaining = append(remaining, Range{From: cur.From, To: e.From.Prev()})
+			}
+
+			if !e.To.Less(cur.To) {
+				done = true
+				break
+			}
+
+			cur.From = e.To.Next()
+		}
+
+		if !done {
+			remaining = append(remaining, cur)
+		}
+	}
+
+	return remaining
+}
+
+// Exclude returns the minimal list of prefixes that cover the addresses in
+// prefixes that aren't in exclude.
+func Exclude(prefixes, exclude []netip.Prefix) []netip.Prefix {
+	var remaining []netip.Prefix
+
+	for _, r := range SubtractRanges(PrefixRanges(prefixes), PrefixRanges(exclude)) {
+		remaining = append(remaining, r.Prefixes()...)
+	}
+
+	return remaining
+}
+
+// PrefixRanges returns the range of addresses in each of prefixes.
+func PrefixRanges(prefixes []netip.Prefix) []Range {
+	ranges := make([]Range, len(prefixes))
+	for i, p := range prefixes {
+		ranges[i] = PrefixRange(p)
+	}
+
+	return ranges
+}
@@ -0,0 +1,232 @@
+package netaddr
+
+import (
+	"math/rand"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  []string
+		output []string
+	}{
+		{"Adjacent", []string{"10.0.1.0/24", "10.0.0.0/24"}, []string{"10.0.0.0/23"}},
+		{"Contained", []string{"87.243.24.122/32", "87.243.24.0/24"}, []string{"87.243.24.0/24"}},
+		{"Unaligned", []string{"10.0.1.0/24", "10.0.2.0/24"}, []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{"Families", []string{"2001:db8:8000::/33", "10.0.0.0/8", "2001:db8::/33"}, []string{"10.0.0.0/8", "2001:db8::/32"}},
+		{"AddressSpace", []string{"128.0.0.0/1", "0.0.0.0/1", "8000::/1", "::/1"}, []string{"0.0.0.0/0", "::/0"}},
+		{"EndOfAddressSpace", []string{"255.255.255.255/32", "255.255.255.254/32"}, []string{"255.255.255.254/31"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			for _, p := range Merge(mustParsePrefixes(t, tt.input)) {
+				got = append(got, p.String())
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.output, ",") {
+				t.Errorf("expected %v but got %v", tt.output, got)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 100; i++ {
+			var prefixes []netip.Prefix
+
+			for j := 0; j < 10; j++ {
+				a := netip.AddrFrom4([4]byte{10, 0, 0, byte(r.Intn(256))})
+				prefixes = append(prefixes, netip.PrefixFrom(a, 24+r.Intn(9)).Masked())
+			}
+
+			if want, got := coverage(prefixes), coverage(Merge(prefixes)); want != got {
+				t.Fatalf("merging %v changed the addresses covered", prefixes)
+			}
+		}
+	})
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		exclude []string
+		output  []string
+	}{
+		{"Middle", []string{"10.0.0.0/24"}, []string{"10.0.0.64/26"}, []string{"10.0.0.0/26", "10.0.0.128/25"}},
+		{"Everything", []string{"10.0.0.0/24"}, []string{"0.0.0.0/0"}, nil},
+		{"Disjoint", []string{"10.0.0.0/24"}, []string{"192.168.0.0/16", "2001:db8::/32"}, []string{"10.0.0.0/24"}},
+		{"Families", []string{"192.168.0.0/24", "2001:db8::/32"}, []string{"192.168.0.128/25", "2001:db8::/33"}, []string{"192.168.0.0/25", "2001:db8:8000::/33"}},
+		{"Spanning", []string{"10.0.0.0/26", "10.0.0.128/26"}, []string{"10.0.0.48/28", "10.0.0.64/26", "10.0.0.128/28"}, []string{"10.0.0.0/27", "10.0.0.32/28", "10.0.0.144/28", "10.0.0.160/27"}},
+		{"Edges", []string{"10.0.0.0/24"}, []string{"10.0.0.0/32", "10.0.0.255/32"}, []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/29", "10.0.0.16/28", "10.0.0.32/27", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/27", "10.0.0.224/28", "10.0.0.240/29", "10.0.0.248/30", "10.0.0.252/31", "10.0.0.254/32"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			for _, p := range Exclude(mustParsePrefixes(t, tt.input), mustParsePrefixes(t, tt.exclude)) {
+				got = append(got, p.String())
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.output, ",") {
+				t.Errorf("expected %v but got %v", tt.output, got)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		random := func() []netip.Prefix {
+			var prefixes []netip.Prefix
+
+			for j := 0; j < 5; j++ {
+				a := netip.AddrFrom4([4]byte{10, 0, 0, byte(r.Intn(256))})
+				prefixes = append(prefixes, netip.PrefixFrom(a, 24+r.Intn(9)).Masked())
+			}
+
+			return prefixes
+		}
+
+		for i := 0; i < 100; i++ {
+			prefixes, exclude := random(), random()
+
+			want := coverage(prefixes)
+			for a, excluded := range coverage(exclude) {
+				want[a] = want[a] && !excluded
+			}
+
+			if got := coverage(Exclude(prefixes, exclude)); want != got {
+				t.Fatalf("excluding %v from %v covered the wrong addresses", exclude, prefixes)
+			}
+		}
+	})
+
+	t.Run("Large", func(t *testing.T) {
+		// Exclude the upper half of 20,000 /30s, which would be slow if every
+		// exclude range were compared with every input range
+		var prefixes, exclude, want []netip.Prefix
+
+		for i := 0; i < 20000; i++ {
+			a := netip.AddrFrom4([4]byte{10, byte(i >> 13), byte(i >> 5), byte(i << 3)})
+
+			prefixes = append(prefixes, netip.PrefixFrom(a, 30))
+			exclude = append(exclude, netip.PrefixFrom(a.Next().Next(), 31))
+			want = append(want, netip.PrefixFrom(a, 31))
+		}
+
+		got := Exclude(prefixes, exclude)
+		if len(got) != len(want) {
+			t.Fatalf("expected %d prefixes but got %d", len(want), len(got))
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("expected %s at %d but got %s", want[i], i, got[i])
+			}
+		}
+	})
+}
+
+func TestRangePrefixes(t *testing.T) {
+	r := Range{From: netip.MustParseAddr("10.0.0.5"), To: netip.MustParseAddr("10.0.1.200")}
+
+	var got []string
+	for _, p := range r.Prefixes() {
+		got = append(got, p.String())
+	}
+
+	want := "10.0.0.5/32,10.0.0.6/31,10.0.0.8/29,10.0.0.16/28,10.0.0.32/27,10.0.0.64/26,10.0.0.128/25,10.0.1.0/25,10.0.1.128/26,10.0.1.192/29,10.0.1.200/32"
+	if strings.Join(got, ",") != want {
+		t.Errorf("expected %s but got %v", want, got)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for _, s := range []string{"10.0.0.5-10.0.1.200", " 10.0.0.5 - 10.0.1.200 ", "2001:db8::-2001:db8::ffff", "10.0.0.5"} {
+		if _, err := ParseRange(s); err != nil {
+			t.Errorf("ParseRange returned an error when one wasn't expected: %+v", err)
+		}
+	}
+
+	for _, s := range []string{"10.0.0.9-10.0.0.1", "10.0.0.1-::1", "10.0.0.1-wibble", ""} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("expected an error parsing '%s'", s)
+		}
+	}
+}
+
+func TestRangePrefixesExact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		// Random IPv6 ranges, which are checked to be covered exactly by
+		// contiguous prefixes
+		var a, b [16]byte
+
+		r.Read(a[:])
+		r.Read(b[:])
+
+		rng, err := NewRange(netip.AddrFrom16(a), netip.AddrFrom16(b))
+		if err != nil {
+			rng, _ = NewRange(netip.AddrFrom16(b), netip.AddrFrom16(a))
+		}
+
+		next := rng.From
+
+		for _, p := range rng.Prefixes() {
+			if p.Addr() != next || p.Masked() != p {
+				t.Fatalf("%s isn't aligned, or doesn't follow on from the previous prefix in %s", p, rng)
+			}
+
+			next = Last(p).Next()
+		}
+
+		if Last(rng.Prefixes()[len(rng.Prefixes())-1]) != rng.To {
+			t.Fatalf("the prefixes for %s don't end at %s", rng, rng.To)
+		}
+	}
+}
+
+// coverage returns which of the addresses in 10.0.0.0/24 are covered by
+// prefixes.
+func coverage(prefixes []netip.Prefix) [256]bool {
+	var c [256]bool
+
+	for i := range c {
+		a := netip.AddrFrom4([4]byte{10, 0, 0, byte(i)})
+
+		for _, p := range prefixes {
+			if p.Contains(a) {
+				c[i] = true
+			}
+		}
+	}
+
+	return c
+}
+
+func mustParsePrefixes(t *testing.T, cidrs []string) []netip.Prefix {
+	t.Helper()
+
+	prefixes := make([]netip.Prefix, len(cidrs))
+
+	for i, c := range cidrs {
+		p, err := ParsePrefix(c)
+		if err != nil {
+			t.Fatalf("ParsePrefix returned an error when one wasn't expected: %+v", err)
+		}
+
+		prefixes[i] = p
+	}
+
+	return prefixes
+}
@@ -0,0 +1,136 @@
+package netaddr
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed special.csv
+var specialCSV string
+
+// Registry is an entry in the IANA special-purpose address registries.
+type Registry struct {
+	Prefix netip.Prefix `json:"prefix"`
+	Name   string       `json:"name"`
+	RFC    string       `json:"rfc"`
+	// Global is whether addresses in the block are reachable across the
+	// internet
+	Global bool `json:"globallyReachable"`
+}
+
+// registries is parsed from special.csv when the package is initialised, as
+// the file is embedded in the binary and can't be invalid at runtime.
+var registries = mustParseRegistries(specialCSV)
+
+func mustParseRegistries(s string) []Registry {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comment = '#'
+
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("error parsing the special-purpose registries: %v", err))
+	}
+
+	regs := make([]Registry, len(records))
+
+	for i, rec := range records {
+		p, err := ParsePrefix(rec[0])
+		if err != nil {
+			panic(fmt.Sprintf("error parsing special-purpose prefix '%s': %v", rec[0], err))
+		}
+
+		global, err := strconv.ParseBool(rec[3])
+		if err != nil {
+			panic(fmt.Sprintf("error parsing special-purpose prefix '%s': %v", rec[0], err))
+		}
+
+		regs[i] = Registry{Prefix: p, Name: rec[1], RFC: rec[2], Global: global}
+	}
+
+	return regs
+}
+
+// SpecialPurpose returns the special-purpose registries that a is in, most
+// specific first.
+func SpecialPurpose(a netip.Addr) []Registry {
+	a = a.WithZone("")
+
+	var matched []Registry
+
+	for _, r := range registries {
+		if r.Prefix.Contains(a) {
+			matched = append(matched, r)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Prefix.Bits() > matched[j].Prefix.Bits()
+	})
+
+	return matched
+}
+
+// IsGlobal returns whether a is reachable across the internet, as given by the
+// most specific special-purpose registry it's in. Addresses that aren't in a
+// registry are global.
+func IsGlobal(a netip.Addr) bool {
+	if regs := SpecialPurpose(a); len(regs) > 0 {
+		return regs[0].Global
+	}
+
+	return true
+}
+
+// EmbeddedIPv4 returns the IPv4 address embedded in an IPv4-mapped, 6to4 or
+// Teredo (the client's public address) IPv6 address, or false if a isn't one.
+func EmbeddedIPv4(a netip.Addr) (netip.Addr, bool) {
+	if !a.Is6() {
+		return netip.Addr{}, false
+	}
+
+	b := a.As16()
+
+	switch {
+	case a.Is4In6():
+		return a.Unmap(), true
+	case b[0] == 0x20 && b[1] == 0x02:
+		return netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]}), true
+	case b[0] == 0x20 && b[1] == 0x01 && b[2] == 0 && b[3] == 0:
+		// Teredo obfuscates the client address by inverting its bits
+		return netip.AddrFrom4([4]byte{^b[12], ^b[13], ^b[14], ^b[15]}), true
+	}
+
+	return netip.Addr{}, false
+}
+
+// Hex returns a as a hexadecimal number, padded to the width of the address.
+func Hex(a netip.Addr) string {
+	return fmt.Sprintf("0x%0*x", a.BitLen()/4, ToInt(a))
+}
+
+// Binary returns a in binary, with the octets of an IPv4 address separated by
+// '.', and the 16 bit groups of an IPv6 address separated by ':'.
+func Binary(a netip.Addr) string {
+	b := a.AsSlice()
+
+	if a.Is4() {
+		octets := make([]string, len(b))
+		for i, o := range b {
+			octets[i] = fmt.Sprintf("%08b", o)
+		}
+
+		return strings.Join(octets, ".")
+	}
+
+	groups := make([]string, len(b)/2)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%016b", uint16(b[i*2])<<8|uint16(b[i*2+1]))
+	}
+
+	return strings.Join(groups, ":")
+}
@@ -0,0 +1,88 @@
+package netaddr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestSpecialPurpose(t *testing.T) {
+	tests := []struct {
+		ip     string
+		name   string
+		global bool
+	}{
+		{"10.1.2.3", "Private-Use", false},
+		{"172.31.255.255", "Private-Use", false},
+		{"100.64.0.1", "Shared Address Space (CGNAT)", false},
+		{"127.0.0.1", "Loopback", false},
+		{"169.254.169.254", "Link Local", false},
+		{"239.255.255.250", "Multicast", true},
+		{"198.51.100.7", "Documentation (TEST-NET-2)", false},
+		{"8.8.8.8", "", true},
+		{"::1", "Loopback Address", false},
+		{"fd12:3456::1", "Unique-Local", false},
+		{"fe80::1%eth0", "Link-Local Unicast", false},
+		{"::ffff:192.168.0.1", "IPv4-mapped Address", false},
+		{"2002:c000:204::1", "6to4", true},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", "Teredo", true},
+		{"2001:db8::1", "Documentation", false},
+		{"2606:4700::1111", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			a := netip.MustParseAddr(tt.ip)
+
+			var name string
+			if regs := SpecialPurpose(a); len(regs) > 0 {
+				name = regs[0].Name
+			}
+
+			if name != tt.name || IsGlobal(a) != tt.global {
+				t.Errorf("expected %q (global %t) but got %q (global %t)", tt.name, tt.global, name, IsGlobal(a))
+			}
+		})
+	}
+}
+
+func TestEmbeddedIPv4(t *testing.T) {
+	tests := []struct {
+		ip       string
+		embedded string
+	}{
+		{"::ffff:10.1.2.3", "10.1.2.3"},
+		{"2002:c000:204::1", "192.0.2.4"},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", "192.0.2.45"},
+		{"2001:db8::1", ""},
+		{"10.1.2.3", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			var got string
+			if a, ok := EmbeddedIPv4(netip.MustParseAddr(tt.ip)); ok {
+				got = a.String()
+			}
+
+			if got != tt.embedded {
+				t.Errorf("expected %q but got %q", tt.embedded, got)
+			}
+		})
+	}
+}
+
+func TestFormats(t *testing.T) {
+	a := netip.MustParseAddr("10.0.0.1")
+
+	if got := Hex(a); got != "0x0a000001" {
+		t.Errorf("expected 0x0a000001 but got %s", got)
+	}
+
+	if got := Binary(a); got != "00001010.00000000.00000000.00000001" {
+		t.Errorf("expected 00001010.00000000.00000000.00000001 but got %s", got)
+	}
+
+	if got := Hex(netip.MustParseAddr("::1")); got != "0x00000000000000000000000000000001" {
+		t.Errorf("expected a 32 digit hex number but got %s", got)
+	}
+}
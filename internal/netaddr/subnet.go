@@ -0,0 +1,52 @@
+package netaddr
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// Split divides p into subnets with the prefix length bits, calling fn for
+// each in turn, so that large splits aren't held in memory. Splitting stops
+// if fn returns false.
+func Split(p netip.Prefix, bits int, fn func(netip.Prefix) bool) error {
+	if bits < p.Bits() || bits > p.Addr().BitLen() {
+		return fmt.Errorf("the prefix length must be between %d and %d", p.Bits(), p.Addr().BitLen())
+	}
+
+	for a := p.Masked().Addr(); a.IsValid() && p.Contains(a); {
+		sub := netip.PrefixFrom(a, bits)
+		if !fn(sub) {
+			return nil
+		}
+
+		a = Last(sub).Next()
+	}
+
+	return nil
+}
+
+// Allocate returns the first prefix with the length bits in parent that
+// doesn't overlap any of the used prefixes, or false if there isn't one.
+func Allocate(parent netip.Prefix, used []netip.Prefix, bits int) (netip.Prefix, bool, error) {
+	if bits < parent.Bits() || bits > parent.Addr().BitLen() {
+		return netip.Prefix{}, false, fmt.Errorf("the prefix length must be between %d and %d", parent.Bits(), parent.Addr().BitLen())
+	}
+
+	for _, r := range SubtractRanges([]Range{PrefixRange(parent)}, PrefixRanges(used)) {
+		// Round the start of the free range up to the next block boundary
+		start := r.From
+		if p := netip.PrefixFrom(start, bits); p.Masked().Addr() != start {
+			start = Last(p).Next()
+		}
+
+		if !start.IsValid() || r.To.Less(start) {
+			continue
+		}
+
+		if p := netip.PrefixFrom(start, bits); !r.To.Less(Last(p)) {
+			return p, true, nil
+		}
+	}
+
+	return netip.Prefix{}, false, nil
+}
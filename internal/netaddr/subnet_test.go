@@ -0,0 +1,79 @@
+package netaddr
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	var got []string
+
+	err := Split(netip.MustParsePrefix("10.0.0.0/16"), 18, func(p netip.Prefix) bool {
+		got = append(got, p.String())
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Split returned an error when one wasn't expected: %+v", err)
+	}
+
+	if want := "10.0.0.0/18,10.0.64.0/18,10.0.128.0/18,10.0.192.0/18"; strings.Join(got, ",") != want {
+		t.Errorf("expected %s but got %v", want, got)
+	}
+
+	t.Run("EndOfAddressSpace", func(t *testing.T) {
+		n := 0
+
+		if err := Split(netip.MustParsePrefix("ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0/124"), 128, func(netip.Prefix) bool { n++; return true }); err != nil {
+			t.Fatalf("Split returned an error when one wasn't expected: %+v", err)
+		}
+
+		if n != 16 {
+			t.Errorf("expected 16 subnets but got %d", n)
+		}
+	})
+
+	t.Run("InvalidPrefix", func(t *testing.T) {
+		if err := Split(netip.MustParsePrefix("10.0.0.0/16"), 8, func(netip.Prefix) bool { return true }); err == nil {
+			t.Errorf("Split was expected to return an error but didn't")
+		}
+	})
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent string
+		used   []string
+		bits   int
+		want   string
+	}{
+		{"Empty", "10.0.0.0/16", nil, 24, "10.0.0.0/24"},
+		{"Gap", "10.0.0.0/16", []string{"10.0.0.0/24", "10.0.2.0/23"}, 24, "10.0.1.0/24"},
+		{"Aligned", "10.0.0.0/16", []string{"10.0.0.0/24", "10.0.2.0/23"}, 22, "10.0.4.0/22"},
+		{"OutsideParent", "10.0.0.0/16", []string{"192.168.0.0/16", "10.0.0.0/17"}, 17, "10.0.128.0/17"},
+		{"IPv6", "2001:db8::/48", []string{"2001:db8::/64", "2001:db8:0:1::/64"}, 64, "2001:db8:0:2::/64"},
+		{"Full", "10.0.0.0/30", []string{"10.0.0.0/31", "10.0.0.2/31"}, 31, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok, err := Allocate(netip.MustParsePrefix(tt.parent), mustParsePrefixes(t, tt.used), tt.bits)
+			if err != nil {
+				t.Fatalf("Allocate returned an error when one wasn't expected: %+v", err)
+			}
+
+			if tt.want == "" {
+				if ok {
+					t.Errorf("expected no free prefix but got %s", p)
+				}
+
+				return
+			}
+
+			if !ok || p.String() != tt.want {
+				t.Errorf("expected %s but got %s (%v)", tt.want, p, ok)
+			}
+		})
+	}
+}
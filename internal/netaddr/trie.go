@@ -0,0 +1,111 @@
+package netaddr
+
+import "net/netip"
+
+// Trie is a binary trie of prefixes, for finding the prefixes that contain an
+// address in at most one step per bit of the address, however many prefixes
+// there are.
+type Trie struct {
+	v4 *trieNode
+	v6 *trieNode
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	prefix   netip.Prefix
+	set      bool
+}
+
+// Insert adds p to the trie.
+func (t *Trie) Insert(p netip.Prefix) {
+	p = p.Masked()
+
+	root := &t.v4
+	if p.Addr().Is6() {
+		root = &t.v6
+	}
+
+	if *root == nil {
+		*root = &trieNode{}
+	}
+
+	n := *root
+	b := p.Addr().AsSlice()
+
+	for i := 0; i < p.Bits(); i++ {
+		bit := bitAt(b, i)
+
+		if n.children[bit] == nil {
+			n.children[bit] = &trieNode{}
+		}
+
+		n = n.children[bit]
+	}
+
+	n.prefix, n.set = p, true
+}
+
+// Lookup returns the prefixes in the trie that contain a, most specific
+// first. IPv4-mapped IPv6 addresses are matched against both IPv4 prefixes
+// and IPv6 prefixes, such as ::ffff:0:0/96.
+func (t *Trie) Lookup(a netip.Addr) []netip.Prefix {
+	a = a.WithZone("")
+
+	if !a.Is4In6() {
+		if a.Is4() {
+			return lookup(t.v4, a)
+		}
+
+		return lookup(t.v6, a)
+	}
+
+	v4, v6 := lookup(t.v4, a.Unmap()), lookup(t.v6, a)
+	if len(v6) == 0 {
+		return v4
+	}
+
+	// Merge the matches, comparing IPv4 prefixes by the length they have in
+	// the IPv4-mapped block, with IPv4 prefixes first if they're the same
+	matched := make([]netip.Prefix, 0, len(v4)+len(v6))
+
+	for len(v4) > 0 || len(v6) > 0 {
+		if len(v6) == 0 || (len(v4) > 0 && v4[0].Bits()+96 >= v6[0].Bits()) {
+			matched, v4 = append(matched, v4[0]), v4[1:]
+		} else {
+			matched, v6 = append(matched, v6[0]), v6[1:]
+		}
+	}
+
+	return matched
+}
+
+// lookup returns the prefixes under n that contain a, most specific first.
+func lookup(n *trieNode, a netip.Addr) []netip.Prefix {
+	var matched []netip.Prefix
+
+	b := a.AsSlice()
+
+	for i := 0; n != nil; i++ {
+		if n.set {
+			matched = append(matched, n.prefix)
+		}
+
+		if i == a.BitLen() {
+			break
+		}
+
+		n = n.children[bitAt(b, i)]
+	}
+
+	// Reverse the matches, which were found least specific first
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+
+	return matched
+}
+
+// bitAt returns the i'th most significant bit of b.
+func bitAt(b []byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,80 @@
+package netaddr
+
+import (
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	var trie Trie
+
+	for _, p := range mustParsePrefixes(t, []string{"10.0.0.0/8", "10.1.0.0/16", "0.0.0.0/0", "10.1.2.3/32", "2001:db8::/32", "::ffff:0:0/96", "::ffff:10.1.0.0/112", "::/0"}) {
+		trie.Insert(p)
+	}
+
+	tests := []struct {
+		ip      string
+		matched []string
+	}{
+		{"10.1.2.3", []string{"10.1.2.3/32", "10.1.0.0/16", "10.0.0.0/8", "0.0.0.0/0"}},
+		{"10.2.0.1", []string{"10.0.0.0/8", "0.0.0.0/0"}},
+		{"::ffff:10.1.0.1", []string{"10.1.0.0/16", "::ffff:10.1.0.0/112", "10.0.0.0/8", "0.0.0.0/0", "::ffff:0.0.0.0/96", "::/0"}},
+		{"::ffff:192.0.2.1", []string{"0.0.0.0/0", "::ffff:0.0.0.0/96", "::/0"}},
+		{"2001:db8::1", []string{"2001:db8::/32", "::/0"}},
+		{"2001:db9::1", []string{"::/0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			var got []string
+			for _, p := range trie.Lookup(netip.MustParseAddr(tt.ip)) {
+				got = append(got, p.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.matched) {
+				t.Errorf("expected %v but got %v", tt.matched, got)
+			}
+		})
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		var (
+			trie     Trie
+			prefixes []netip.Prefix
+		)
+
+		for i := 0; i < 200; i++ {
+			a := netip.AddrFrom4([4]byte{10, byte(r.Intn(4)), byte(r.Intn(256)), byte(r.Intn(256))})
+			p := netip.PrefixFrom(a, 14+r.Intn(19)).Masked()
+
+			prefixes = append(prefixes, p)
+			trie.Insert(p)
+		}
+
+		for i := 0; i < 1000; i++ {
+			a := netip.AddrFrom4([4]byte{10, byte(r.Intn(4)), byte(r.Intn(256)), byte(r.Intn(256))})
+
+			want := make(map[netip.Prefix]bool)
+			for _, p := range prefixes {
+				if p.Contains(a) {
+					want[p] = true
+				}
+			}
+
+			got := trie.Lookup(a)
+			if len(got) != len(want) {
+				t.Fatalf("expected %d matches for %s but got %d", len(want), a, len(got))
+			}
+
+			for _, p := range got {
+				if !want[p] {
+					t.Fatalf("%s doesn't contain %s", p, a)
+				}
+			}
+		}
+	})
+}
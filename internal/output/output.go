@@ -0,0 +1,55 @@
+// Package output implements the output formats shared by genc commands.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// Format implements a custom type to be used with Cobra.
+//
+// It ensures that the output format is one of text or json.
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+)
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(v string) error {
+	switch Format(v) {
+	case Text, JSON:
+		*f = Format(v)
+		return nil
+	default:
+		return errors.New(`must be one of text, or json`)
+	}
+}
+
+func (f *Format) Type() string {
+	return "[text,json]"
+}
+
+// WriteJSON writes v to w as indented JSON.
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// WriteFile writes data to the file at path, or to stdout if path is empty.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(path, data, perm)
+}